@@ -0,0 +1,61 @@
+package gqlcost
+
+import (
+	"errors"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/visitor"
+)
+
+// OperationCost provides cost of an operation.
+type OperationCost struct {
+	// Name is name of the operation. It is empty for anonymous operation.
+	Name string `json:"name,omitempty"`
+
+	// Operation is type of the operation: "query", "mutation" or
+	// "subscription".
+	Operation string `json:"operation"`
+
+	// Cost is computed cost of the operation.
+	Cost int `json:"cost"`
+
+	// Breakdown provides cost breakdown for each selection of the operation.
+	// It is available only when AnalysisOptions.Breakdown is true.
+	Breakdown []*CostBreakdown `json:"breakdown,omitempty"`
+}
+
+// Result provides result of cost analysis.
+type Result struct {
+	// Cost is total cost of all evaluated operations in the document.
+	Cost int `json:"cost"`
+
+	// Operations enumerates costs of each operation in the document.
+	Operations []OperationCost `json:"operations,omitempty"`
+}
+
+// Analyze computes cost of the document without validation.
+// It returns a Result even if some errors are detected, and the error
+// joins all errors which are reported by cost analysis (ex. exceeding
+// MaximumCost).
+func Analyze(schema *graphql.Schema, doc *ast.Document, opts AnalysisOptions) (Result, error) {
+	if schema == nil {
+		return Result{}, errors.New("gqlcost: must provide schema")
+	}
+	if doc == nil {
+		return Result{}, errors.New("gqlcost: must provide document")
+	}
+	typeInfo := graphql.NewTypeInfo(&graphql.TypeInfoConfig{Schema: schema})
+	ctx := graphql.NewValidationContext(schema, doc, typeInfo)
+	ca := newCostAnalysis(ctx, opts)
+	visitor.Visit(doc, ca.visitorOptions(), nil)
+	r := Result{
+		Cost:       ca.cost,
+		Operations: ca.operations,
+	}
+	var errs []error
+	for _, err := range ctx.Errors() {
+		errs = append(errs, err)
+	}
+	return r, errors.Join(errs...)
+}
@@ -0,0 +1,63 @@
+package gqlcost
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+func TestAnalyze(t *testing.T) {
+	doc := parseQuery(t, `
+		query A { customCost }
+		query B { customCost defaultCost }
+		mutation { setName(name: "foo") }`)
+	r, err := Analyze(schema, doc, AnalysisOptions{
+		CostMap: CostMap{
+			"Query":    {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+			"Mutation": {Fields: FieldsCost{"setName": {Complexity: 3}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exp := Result{
+		Cost: 19,
+		Operations: []OperationCost{
+			{Name: "A", Operation: "query", Cost: 8},
+			{Name: "B", Operation: "query", Cost: 8},
+			{Name: "", Operation: "mutation", Cost: 3},
+		},
+	}
+	if !reflect.DeepEqual(r, exp) {
+		t.Fatalf("unexpected result:\nwant=%+v\ngot=%+v", exp, r)
+	}
+}
+
+func TestAnalyze_Exceeded(t *testing.T) {
+	doc := parseQuery(t, `query { customCost }`)
+	r, err := Analyze(schema, doc, AnalysisOptions{
+		MaximumCost: 1,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+		},
+	})
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	var fe gqlerrors.FormattedError
+	if !errors.As(err, &fe) {
+		t.Fatalf("error should be FormattedError: %T", err)
+	}
+	if r.Cost != 8 {
+		t.Fatalf("wrong cost: want=%d got=%d", 8, r.Cost)
+	}
+}
+
+func TestAnalyze_NoSchema(t *testing.T) {
+	_, err := Analyze(nil, parseQuery(t, `query { customCost }`), AnalysisOptions{})
+	if err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -0,0 +1,85 @@
+package gqlcost
+
+import "github.com/graphql-go/graphql/language/ast"
+
+// CostBreakdown provides cost breakdown of a selection: a field or a
+// fragment. Breakdowns are organized as a tree which mirrors the selection
+// set.
+type CostBreakdown struct {
+	// Name is name of the field, or name of the fragment like "...Foo",
+	// "... on Bar" or "...".
+	Name string `json:"name"`
+
+	// Alias is alias of the field.
+	Alias string `json:"alias,omitempty"`
+
+	// Path is a path to the field from the operation, separated by ".",
+	// like "users.posts". Aliases are used instead of names when available.
+	// Fragments don't appear in paths.
+	Path string `json:"path"`
+
+	// Complexity is complexity of the field.
+	Complexity int `json:"complexity,omitempty"`
+
+	// Multipliers are multipliers applied to the complexity, includes ones
+	// of parents.
+	Multipliers []int `json:"multipliers,omitempty"`
+
+	// Cost is cost of the selection itself, without children.
+	Cost int `json:"cost"`
+
+	// Total is subtotal of cost of the selection, includes children.
+	Total int `json:"total"`
+
+	// Children is breakdowns of child selections.
+	Children []*CostBreakdown `json:"children,omitempty"`
+}
+
+// Walk calls fn for each breakdown in the tree in depth first order.
+func (b *CostBreakdown) Walk(fn func(*CostBreakdown)) {
+	if b == nil {
+		return
+	}
+	fn(b)
+	for _, c := range b.Children {
+		c.Walk(fn)
+	}
+}
+
+func (b *CostBreakdown) addField(node *ast.Field) *CostBreakdown {
+	if b == nil {
+		return nil
+	}
+	c := &CostBreakdown{Name: node.Name.Value}
+	key := c.Name
+	if node.Alias != nil && node.Alias.Value != "" {
+		c.Alias = node.Alias.Value
+		key = c.Alias
+	}
+	if b.Path == "" {
+		c.Path = key
+	} else {
+		c.Path = b.Path + "." + key
+	}
+	b.Children = append(b.Children, c)
+	return c
+}
+
+func (b *CostBreakdown) addFragment(name string) *CostBreakdown {
+	if b == nil {
+		return nil
+	}
+	c := &CostBreakdown{Name: name, Path: b.Path}
+	b.Children = append(b.Children, c)
+	return c
+}
+
+func (b *CostBreakdown) set(complexity int, multipliers []int, cost, total int) {
+	if b == nil {
+		return
+	}
+	b.Complexity = complexity
+	b.Multipliers = copyInts(multipliers)
+	b.Cost = cost
+	b.Total = total
+}
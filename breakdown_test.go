@@ -0,0 +1,105 @@
+package gqlcost
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBreakdown(t *testing.T) {
+	doc := parseQuery(t, `
+		query {
+			first(limit: 10) {
+				s: second(limit: 10) {
+					third(limit: 10)
+				}
+				...frag
+			}
+		}
+		fragment frag on First {
+			string
+		}`)
+	r, err := Analyze(schema, doc, AnalysisOptions{
+		Breakdown: true,
+		CostMap: CostMap{
+			"Query":  {Fields: FieldsCost{"first": limitCost(2)}},
+			"First":  {Fields: FieldsCost{"second": limitCost(5), "string": {Complexity: 1}}},
+			"Second": {Fields: FieldsCost{"third": limitCost(6)}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exp := []*CostBreakdown{
+		{
+			Name: "first", Path: "first", Complexity: 2, Multipliers: []int{10}, Cost: 20, Total: 6521,
+			Children: []*CostBreakdown{
+				{
+					Name: "second", Alias: "s", Path: "first.s", Complexity: 5, Multipliers: []int{10, 10}, Cost: 500, Total: 6500,
+					Children: []*CostBreakdown{
+						{Name: "third", Path: "first.s.third", Complexity: 6, Multipliers: []int{10, 10, 10}, Cost: 6000, Total: 6000},
+					},
+				},
+				{
+					Name: "...frag", Path: "first", Total: 1,
+					Children: []*CostBreakdown{
+						{Name: "string", Path: "first.string", Complexity: 1, Cost: 1, Total: 1},
+					},
+				},
+			},
+		},
+	}
+	if len(r.Operations) != 1 {
+		t.Fatalf("one operation expected: %+v", r.Operations)
+	}
+	if !reflect.DeepEqual(r.Operations[0].Breakdown, exp) {
+		t.Fatalf("unexpected breakdown:\nwant=%s\ngot=%s", dumpBreakdown(exp), dumpBreakdown(r.Operations[0].Breakdown))
+	}
+
+	var paths []string
+	for _, b := range r.Operations[0].Breakdown {
+		b.Walk(func(b *CostBreakdown) { paths = append(paths, b.Path) })
+	}
+	if !reflect.DeepEqual(paths, []string{"first", "first.s", "first.s.third", "first", "first.string"}) {
+		t.Fatalf("unexpected paths: %+v", paths)
+	}
+}
+
+func TestBreakdown_InErrors(t *testing.T) {
+	doc := parseQuery(t, `query { customCost }`)
+	opts := AnalysisOptions{
+		MaximumCost: 1,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+		},
+	}
+	vr := ValidateDocument(schema, doc, opts)
+	if len(vr.Errors) != 1 {
+		t.Fatalf("one error expected: %+v", vr.Errors)
+	}
+	if _, ok := vr.Errors[0].Extensions["breakdown"]; ok {
+		t.Fatalf("breakdown should not be in extensions: %+v", vr.Errors[0].Extensions)
+	}
+
+	opts.BreakdownInErrors = true
+	vr = ValidateDocument(schema, doc, opts)
+	if len(vr.Errors) != 1 {
+		t.Fatalf("one error expected: %+v", vr.Errors)
+	}
+	bd, ok := vr.Errors[0].Extensions["breakdown"].([]*CostBreakdown)
+	if !ok || len(bd) != 1 || bd[0].Path != "customCost" || bd[0].Total != 8 {
+		t.Fatalf("unexpected breakdown in extensions: %+v", vr.Errors[0].Extensions)
+	}
+}
+
+func dumpBreakdown(bds []*CostBreakdown) string {
+	var s string
+	for _, b := range bds {
+		b.Walk(func(b *CostBreakdown) {
+			c := *b
+			c.Children = nil
+			s += fmt.Sprintf("\n  %+v", c)
+		})
+	}
+	return s
+}
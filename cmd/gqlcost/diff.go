@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/koron-go/gqlcost"
+)
+
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gqlcost diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: gqlcost diff [flags] -base {old cost map} -costmap {new cost map} {directories or manifest files}")
+		fs.PrintDefaults()
+	}
+	var (
+		cfg    config
+		base   string
+		format string
+		sortBy string
+		all    bool
+	)
+	cfg.register(fs)
+	fs.StringVar(&base, "base", "", "cost map file to be compared with -costmap (required)")
+	fs.StringVar(&format, "format", "table", "output format: table, csv or json")
+	fs.StringVar(&sortBy, "sort", "delta", "sort key: delta (descending) or id")
+	fs.BoolVar(&all, "all", false, "print operations which cost is not changed")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 || base == "" {
+		fs.Usage()
+		return exitError
+	}
+	baseCfg := cfg
+	baseCfg.costMap = base
+	oldA, err := baseCfg.analyzer(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+	newA, err := cfg.analyzer(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+	queries, err := loadCorpus(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+
+	oldOpts, newOpts := oldA.opts, newA.opts
+	oldOpts.OperationName = ""
+	newOpts.OperationName = ""
+	var entries []gqlcost.DiffEntry
+	for _, e := range gqlcost.Diff(newA.schema, queries, oldOpts, newOpts) {
+		if all || e.Delta != 0 || e.Error != "" {
+			entries = append(entries, e)
+		}
+	}
+	switch sortBy {
+	case "delta":
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Delta > entries[j].Delta
+		})
+	case "id":
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].ID < entries[j].ID
+		})
+	default:
+		fmt.Fprintf(stderr, "gqlcost: unknown sort key: %s\n", sortBy)
+		return exitError
+	}
+
+	switch format {
+	case "table":
+		err = writeDiffTable(stdout, entries)
+	case "csv":
+		err = writeDiffCSV(stdout, entries)
+	case "json":
+		err = writeJSON(stdout, entries)
+	default:
+		err = fmt.Errorf("unknown format: %s", format)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+	for _, e := range entries {
+		if e.Rejected() || e.Error != "" {
+			return exitExceeded
+		}
+	}
+	return exitOK
+}
+
+// diffStatus returns a status of crossing the maximum cost.
+func diffStatus(e gqlcost.DiffEntry) string {
+	switch {
+	case e.Rejected():
+		return "rejected"
+	case e.Accepted():
+		return "accepted"
+	}
+	return ""
+}
+
+func writeDiffTable(w io.Writer, entries []gqlcost.DiffEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tOLD\tNEW\tDELTA\tID\tOPERATION\tERROR")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%+d\t%s\t%s\t%s\n", diffStatus(e), e.OldCost, e.NewCost, e.Delta, e.ID, operationLabel(e.Operation, e.Name), e.Error)
+	}
+	return tw.Flush()
+}
+
+func writeDiffCSV(w io.Writer, entries []gqlcost.DiffEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "operation", "name", "oldCost", "newCost", "delta", "status", "error"})
+	for _, e := range entries {
+		cw.Write([]string{
+			e.ID,
+			e.Operation,
+			e.Name,
+			strconv.Itoa(e.OldCost),
+			strconv.Itoa(e.NewCost),
+			strconv.Itoa(e.Delta),
+			diffStatus(e),
+			e.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
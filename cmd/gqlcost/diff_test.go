@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/koron-go/gqlcost"
+)
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeFile(t, dir, "schema.graphql", testSDL)
+	base := writeFile(t, dir, "base.yaml", `{}`)
+	costMap := writeFile(t, dir, "costmap.yaml", `
+User:
+  fields:
+    posts:
+      complexity: 1
+      useMultipliers: true
+      multipliers: [first]
+    name:
+      complexity: 1
+`)
+	manifest := writeFile(t, dir, "manifest.json", `{
+		"a": "query A { users(first: 10) { posts(first: 10) { title } } }",
+		"b": "query B { users(first: 5) { name } }",
+		"c": "query C { users(first: 1) { __typename } }"
+	}`)
+
+	code, out, errOut := runTest(t, "diff", "-schema", schema, "-base", base, "-costmap", costMap, "-max", "150", manifest)
+	if code != exitOK {
+		t.Fatalf("unexpected exit code: %d\n%s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	for i, exp := range []string{
+		"STATUS OLD NEW DELTA ID OPERATION ERROR",
+		"5 6 +1 b query B",
+		"accepted 210 110 -100 a query A",
+	} {
+		if got := strings.Join(strings.Fields(lines[i]), " "); got != exp {
+			t.Errorf("unexpected line #%d:\nwant=%s\ngot=%s", i, exp, got)
+		}
+	}
+
+	code, out, errOut = runTest(t, "diff", "-schema", schema, "-base", costMap, "-costmap", base, "-max", "150", "-format", "json", "-all", "-sort", "id", manifest)
+	if code != exitExceeded {
+		t.Fatalf("unexpected exit code: %d\n%s", code, errOut)
+	}
+	var entries []gqlcost.DiffEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if e := entries[0]; e.ID != "a" || !e.Rejected() || e.OldCost != 110 || e.NewCost != 210 {
+		t.Errorf("unexpected entry for a: %+v", e)
+	}
+	if e := entries[2]; e.ID != "c" || e.Delta != 0 {
+		t.Errorf("unexpected entry for c: %+v", e)
+	}
+
+	code, _, errOut = runTest(t, "diff", "-schema", schema, "-costmap", costMap, manifest)
+	if code != exitError || !strings.Contains(errOut, "Usage:") {
+		t.Fatalf("unexpected result without -base: %d\n%s", code, errOut)
+	}
+}
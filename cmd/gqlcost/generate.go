@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/koron-go/gqlcost"
+	"gopkg.in/yaml.v3"
+)
+
+func runGenerate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gqlcost generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: gqlcost generate [flags] -schema {SDL file}")
+		fs.PrintDefaults()
+	}
+	var (
+		schemaFile  string
+		format      string
+		pkg         string
+		varName     string
+		multipliers string
+		gopts       gqlcost.GenerateOptions
+	)
+	fs.StringVar(&schemaFile, "schema", "", "SDL file of the schema (required)")
+	fs.StringVar(&format, "format", "json", "output format: json, yaml or go")
+	fs.StringVar(&pkg, "package", "main", "package name for -format go")
+	fs.StringVar(&varName, "var", "costMap", "variable name for -format go")
+	fs.StringVar(&multipliers, "multipliers", "", "comma separated names of arguments for multipliers (default \"first,last,limit,pageSize\")")
+	fs.IntVar(&gopts.MutationComplexity, "mutation", 0, "complexity of mutation fields (default 10)")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if schemaFile == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+	if multipliers != "" {
+		gopts.Multipliers = strings.Split(multipliers, ",")
+	}
+	sdl, err := os.ReadFile(schemaFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+	schema, _, err := gqlcost.BuildSchema(string(sdl))
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: failed to build schema: %s\n", err)
+		return exitError
+	}
+
+	m := gqlcost.GenerateCostMap(schema, gopts)
+	switch format {
+	case "json":
+		err = writeJSON(stdout, m)
+	case "yaml":
+		enc := yaml.NewEncoder(stdout)
+		enc.SetIndent(2)
+		err = enc.Encode(m)
+		if err == nil {
+			err = enc.Close()
+		}
+	case "go":
+		err = m.WriteGo(stdout, pkg, varName)
+	default:
+		err = fmt.Errorf("unknown format: %s", format)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koron-go/gqlcost"
+)
+
+const generateSDL = `
+type Query {
+  users(first: Int, last: Int): [User]
+}
+
+type User {
+  name: String
+  profile: Profile
+}
+
+type Profile {
+  bio: String
+}
+
+type Mutation {
+  addUser(name: String): User
+}
+`
+
+func TestRunGenerate(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeFile(t, dir, "schema.graphql", generateSDL)
+
+	code, out, errOut := runTest(t, "generate", "-schema", schema, "-format", "yaml", "-mutation", "5")
+	if code != exitOK {
+		t.Fatalf("unexpected exit code: %d\n%s", code, errOut)
+	}
+	m, err := gqlcost.LoadCostMap(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("failed to load generated cost map: %s\n%s", err, out)
+	}
+	if c := m["Mutation"].Fields["addUser"]; c.Complexity != 5 {
+		t.Errorf("unexpected cost of addUser: %+v", c)
+	}
+	if c := m["Query"].Fields["users"]; !c.UseMultipliers || c.Strategy != gqlcost.StrategyMax {
+		t.Errorf("unexpected cost of users: %+v", c)
+	}
+	// SDL has no resolvers, so fields which return objects cost.
+	if c := m["User"].Fields["profile"]; c.Complexity != 1 || !c.UseMultipliers {
+		t.Errorf("unexpected cost of profile: %+v", c)
+	}
+	if _, ok := m["User"].Fields["name"]; ok {
+		t.Errorf("name should be omitted: %+v", m["User"])
+	}
+
+	code, out, _ = runTest(t, "generate", "-schema", schema, "-format", "go", "-package", "schema", "-multipliers", "first")
+	if code != exitOK {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if !strings.HasPrefix(out, "package schema\n") || !strings.Contains(out, `Multipliers: []string{"first"}`) {
+		t.Fatalf("unexpected source:\n%s", out)
+	}
+
+	code, _, _ = runTest(t, "generate", "-schema", schema, "-format", "xml")
+	if code != exitError {
+		t.Fatalf("unexpected exit code for unknown format: %d", code)
+	}
+}
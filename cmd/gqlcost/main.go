@@ -0,0 +1,282 @@
+/*
+Gqlcost computes cost of GraphQL queries against a schema.
+
+Usage:
+
+	gqlcost [flags] {query files}
+	gqlcost report [flags] {directories or manifest files}
+	gqlcost diff [flags] -base {cost map} {directories or manifest files}
+	gqlcost generate [flags] -schema {SDL file}
+
+The first form prints total cost, costs for each operation and cost
+breakdowns for each query file.
+
+"report" prints a cost report of all operations in directories of query
+files or persisted query manifests (JSON objects from hashes to queries), as
+a table, CSV or JSON. Operations which exceed -max are highlighted.
+
+"diff" compares costs of operations with the cost map given by -base and
+the one given by -costmap. It prints changes of costs, and which operations
+are newly rejected or accepted by -max.
+
+"generate" generates a starting cost map from the schema by heuristics, as
+JSON, YAML or Go source. See gqlcost.GenerateCostMap for details.
+
+Exit status is 1 when some queries are invalid or exceed the maximum cost
+(newly exceed for "diff"), and 2 for other errors.
+
+The schema is given by SDL, and its @cost directives are used as a cost map.
+A cost map file (JSON or YAML) overrides them for each type.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/koron-go/gqlcost"
+)
+
+const (
+	exitOK       = 0
+	exitExceeded = 1
+	exitError    = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// config provides options which are common for commands.
+type config struct {
+	schema      string
+	costMap     string
+	variables   string
+	operation   string
+	maximumCost int
+	defaultCost int
+	listSize    int
+	connection  bool
+}
+
+func (c *config) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.schema, "schema", "", "SDL file of the schema (required)")
+	fs.StringVar(&c.costMap, "costmap", "", "cost map file (JSON or YAML)")
+	fs.StringVar(&c.variables, "variables", "", "JSON file of variables")
+	fs.StringVar(&c.operation, "operation", "", "name of operation to be evaluated")
+	fs.IntVar(&c.maximumCost, "max", 0, "maximum cost, no limits when zero")
+	fs.IntVar(&c.defaultCost, "defaultcost", 0, "default cost of fields")
+	fs.IntVar(&c.listSize, "listsize", 0, "assumed size of lists without multipliers")
+	fs.BoolVar(&c.connection, "connection", false, "enable Relay connection aware costing")
+}
+
+// analyzer analyzes queries with a schema and options.
+type analyzer struct {
+	schema *graphql.Schema
+	opts   gqlcost.AnalysisOptions
+}
+
+func (c *config) analyzer(stderr io.Writer) (*analyzer, error) {
+	if c.schema == "" {
+		return nil, errors.New("-schema is required")
+	}
+	sdl, err := os.ReadFile(c.schema)
+	if err != nil {
+		return nil, err
+	}
+	schema, costMap, err := gqlcost.BuildSchema(string(sdl))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schema: %w", err)
+	}
+	if c.costMap != "" {
+		m, err := loadCostMap(c.costMap)
+		if err != nil {
+			return nil, err
+		}
+		for k := range costMap {
+			// entries for list and non-null types are copies of named
+			// types, to be overridden with them.
+			if strings.ContainsAny(k, "[]!") {
+				delete(costMap, k)
+			}
+		}
+		for k, v := range m {
+			costMap[k] = v
+		}
+		costMap.AddWrappedTypes(schema)
+	}
+	for _, err := range costMap.Validate(schema) {
+		fmt.Fprintf(stderr, "warning: %s\n", err)
+	}
+	opts := gqlcost.AnalysisOptions{
+		MaximumCost:     c.maximumCost,
+		DefaultCost:     c.defaultCost,
+		OperationName:   c.operation,
+		Breakdown:       true,
+		CostMap:         costMap,
+		DefaultListSize: c.listSize,
+		Connection:      gqlcost.ConnectionOptions{Enabled: c.connection},
+	}
+	if c.variables != "" {
+		b, err := os.ReadFile(c.variables)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &opts.Valiables); err != nil {
+			return nil, fmt.Errorf("failed to parse variables: %w", err)
+		}
+	}
+	return &analyzer{schema: schema, opts: opts}, nil
+}
+
+func loadCostMap(name string) (gqlcost.CostMap, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return gqlcost.LoadCostMap(f)
+}
+
+// analyze computes cost of a query. errs are errors of the query: syntax
+// errors, validation errors and exceeding the maximum cost.
+func (a *analyzer) analyze(query string) (r gqlcost.Result, errs []error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return gqlcost.Result{}, []error{err}
+	}
+	vr := graphql.ValidateDocument(a.schema, doc, graphql.SpecifiedRules)
+	if !vr.IsValid {
+		for _, err := range vr.Errors {
+			errs = append(errs, err)
+		}
+		return gqlcost.Result{}, errs
+	}
+	r, err = gqlcost.Analyze(a.schema, doc, a.opts)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return r, errs
+}
+
+// fileResult is a result of analysis for a query file.
+type fileResult struct {
+	File       string                  `json:"file"`
+	Cost       int                     `json:"cost"`
+	Operations []gqlcost.OperationCost `json:"operations,omitempty"`
+	Errors     []string                `json:"errors,omitempty"`
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "report":
+			return runReport(args[1:], stdout, stderr)
+		case "diff":
+			return runDiff(args[1:], stdout, stderr)
+		case "generate":
+			return runGenerate(args[1:], stdout, stderr)
+		}
+	}
+	return runCost(args, stdout, stderr)
+}
+
+func runCost(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gqlcost", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: gqlcost [flags] {query files}")
+		fs.PrintDefaults()
+	}
+	var (
+		cfg       config
+		breakdown bool
+		jsonOut   bool
+	)
+	cfg.register(fs)
+	fs.BoolVar(&breakdown, "breakdown", true, "print cost breakdowns")
+	fs.BoolVar(&jsonOut, "json", false, "print results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	a, err := cfg.analyzer(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+
+	code := exitOK
+	var results []fileResult
+	for _, name := range fs.Args() {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+			return exitError
+		}
+		r, errs := a.analyze(string(b))
+		fr := fileResult{File: name, Cost: r.Cost, Operations: r.Operations}
+		for _, err := range errs {
+			fr.Errors = append(fr.Errors, err.Error())
+		}
+		if len(errs) > 0 {
+			code = exitExceeded
+		}
+		if jsonOut {
+			results = append(results, fr)
+			continue
+		}
+		printResult(stdout, fr, breakdown)
+		for _, s := range fr.Errors {
+			fmt.Fprintf(stderr, "%s: %s\n", name, s)
+		}
+	}
+	if jsonOut {
+		if err := writeJSON(stdout, results); err != nil {
+			fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+			return exitError
+		}
+	}
+	return code
+}
+
+func printResult(w io.Writer, fr fileResult, breakdown bool) {
+	fmt.Fprintf(w, "%s: %d\n", fr.File, fr.Cost)
+	for _, oc := range fr.Operations {
+		fmt.Fprintf(w, "  %s: %d\n", operationLabel(oc.Operation, oc.Name), oc.Cost)
+		if !breakdown {
+			continue
+		}
+		for _, b := range oc.Breakdown {
+			printBreakdown(w, b, 2)
+		}
+	}
+}
+
+func printBreakdown(w io.Writer, b *gqlcost.CostBreakdown, depth int) {
+	name := b.Name
+	if b.Alias != "" {
+		name = b.Alias + ": " + name
+	}
+	fmt.Fprintf(w, "%s%s: cost=%d total=%d", strings.Repeat("  ", depth), name, b.Cost, b.Total)
+	if b.Complexity != 0 {
+		fmt.Fprintf(w, " complexity=%d", b.Complexity)
+	}
+	if len(b.Multipliers) > 0 {
+		fmt.Fprintf(w, " multipliers=%v", b.Multipliers)
+	}
+	fmt.Fprintln(w)
+	for _, c := range b.Children {
+		printBreakdown(w, c, depth+1)
+	}
+}
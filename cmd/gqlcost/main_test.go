@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSDL = `
+directive @cost(complexity: Int, multipliers: [String], useMultipliers: Boolean) on OBJECT | FIELD_DEFINITION
+
+type Query {
+  users(first: Int): [User] @cost(complexity: 1, multipliers: ["first"])
+}
+
+type User {
+  name: String
+  posts(first: Int): [Post] @cost(complexity: 2, multipliers: ["first"])
+}
+
+type Post {
+  title: String
+}
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func runTest(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeFile(t, dir, "schema.graphql", testSDL)
+	query := writeFile(t, dir, "query.graphql",
+		`query Users($n: Int) { users(first: $n) { name posts(first: 5) { title } } }`)
+	vars := writeFile(t, dir, "vars.json", `{"n": 10}`)
+
+	code, out, errOut := runTest(t, "-schema", schema, "-variables", vars, query)
+	if code != exitOK {
+		t.Fatalf("unexpected exit code: %d\n%s", code, errOut)
+	}
+	exp := query + `: 110
+  query Users: 110
+    users: cost=10 total=110 complexity=1 multipliers=[10]
+      name: cost=0 total=0
+      posts: cost=100 total=100 complexity=2 multipliers=[10 5]
+        title: cost=0 total=0
+`
+	if out != exp {
+		t.Fatalf("unexpected output:\nwant=%s\ngot=%s", exp, out)
+	}
+
+	code, _, errOut = runTest(t, "-schema", schema, "-variables", vars, "-max", "100", query)
+	if code != exitExceeded {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if !strings.Contains(errOut, "exceeds the maximum cost of 100") {
+		t.Fatalf("unexpected error output: %s", errOut)
+	}
+}
+
+func TestRun_CostMap(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeFile(t, dir, "schema.graphql", testSDL)
+	costMap := writeFile(t, dir, "costmap.yaml", `
+User:
+  fields:
+    posts:
+      complexity: 3
+      useMultipliers: true
+      multipliers: [first]
+`)
+	query := writeFile(t, dir, "query.graphql", `{ users(first: 2) { posts(first: 5) { title } } }`)
+
+	code, out, errOut := runTest(t, "-schema", schema, "-costmap", costMap, "-json", query)
+	if code != exitOK {
+		t.Fatalf("unexpected exit code: %d\n%s", code, errOut)
+	}
+	var results []fileResult
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Cost != 32 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestRun_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeFile(t, dir, "schema.graphql", testSDL)
+	query := writeFile(t, dir, "query.graphql", `{ unknown }`)
+
+	code, _, errOut := runTest(t, "-schema", schema, query)
+	if code != exitExceeded {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if !strings.Contains(errOut, "unknown") {
+		t.Fatalf("unexpected error output: %s", errOut)
+	}
+
+	code, _, _ = runTest(t, query)
+	if code != exitError {
+		t.Fatalf("unexpected exit code without schema: %d", code)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/koron-go/gqlcost"
+)
+
+func runReport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gqlcost report", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: gqlcost report [flags] {directories or manifest files}")
+		fs.PrintDefaults()
+	}
+	var (
+		cfg    config
+		format string
+		sortBy string
+	)
+	cfg.register(fs)
+	fs.StringVar(&format, "format", "table", "output format: table, csv or json")
+	fs.StringVar(&sortBy, "sort", "cost", "sort key: cost (descending) or id")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	a, err := cfg.analyzer(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+	queries, err := loadCorpus(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+
+	opts := a.opts
+	opts.OperationName = ""
+	entries := gqlcost.Report(a.schema, queries, opts)
+	switch sortBy {
+	case "cost":
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Cost > entries[j].Cost
+		})
+	case "id":
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].ID < entries[j].ID
+		})
+	default:
+		fmt.Fprintf(stderr, "gqlcost: unknown sort key: %s\n", sortBy)
+		return exitError
+	}
+
+	switch format {
+	case "table":
+		err = writeTable(stdout, entries)
+	case "csv":
+		err = writeCSV(stdout, entries)
+	case "json":
+		err = writeJSON(stdout, entries)
+	default:
+		err = fmt.Errorf("unknown format: %s", format)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "gqlcost: %s\n", err)
+		return exitError
+	}
+	for _, e := range entries {
+		if e.Exceeded || e.Error != "" {
+			return exitExceeded
+		}
+	}
+	return exitOK
+}
+
+// loadCorpus loads queries from directories of query files or persisted
+// query manifests.
+func loadCorpus(names []string) ([]gqlcost.PersistedQuery, error) {
+	var queries []gqlcost.PersistedQuery
+	for _, name := range names {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		if fi.IsDir() {
+			qs, err := gqlcost.LoadQueries(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, q := range qs {
+				q.ID = path.Join(filepath.ToSlash(name), q.ID)
+				queries = append(queries, q)
+			}
+			continue
+		}
+		qs, err := loadManifest(name)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, qs...)
+	}
+	return queries, nil
+}
+
+func loadManifest(name string) ([]gqlcost.PersistedQuery, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return gqlcost.LoadManifest(f)
+}
+
+func operationLabel(operation, name string) string {
+	if name == "" {
+		return operation
+	}
+	return operation + " " + name
+}
+
+func writeTable(w io.Writer, entries []gqlcost.ReportEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tCOST\tID\tOPERATION\tERROR")
+	for _, e := range entries {
+		mark := ""
+		if e.Exceeded {
+			mark = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", mark, e.Cost, e.ID, operationLabel(e.Operation, e.Name), e.Error)
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, entries []gqlcost.ReportEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "operation", "name", "cost", "exceeded", "error"})
+	for _, e := range entries {
+		cw.Write([]string{
+			e.ID,
+			e.Operation,
+			e.Name,
+			strconv.Itoa(e.Cost),
+			strconv.FormatBool(e.Exceeded),
+			e.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/koron-go/gqlcost"
+)
+
+func TestRunReport(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeFile(t, dir, "schema.graphql", testSDL)
+	queries := filepath.Join(dir, "queries")
+	if err := os.Mkdir(queries, 0777); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, queries, "small.graphql", `query Small { users(first: 1) { name } }`)
+	writeFile(t, queries, "large.graphql", `query Large { users(first: 10) { posts(first: 10) { title } } }`)
+	manifest := writeFile(t, dir, "manifest.json", `{"abc": "query Medium { users(first: 50) { name } }"}`)
+
+	code, out, errOut := runTest(t, "report", "-schema", schema, "-max", "100", queries, manifest)
+	if code != exitExceeded {
+		t.Fatalf("unexpected exit code: %d\n%s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	for i, s := range []string{"COST", "* 210", "50", "1"} {
+		if got := strings.Join(strings.Fields(lines[i])[:len(strings.Fields(s))], " "); got != s {
+			t.Errorf("unexpected line #%d: %q", i, lines[i])
+		}
+	}
+
+	code, out, errOut = runTest(t, "report", "-schema", schema, "-format", "json", "-sort", "id", queries, manifest)
+	if code != exitOK {
+		t.Fatalf("unexpected exit code: %d\n%s", code, errOut)
+	}
+	var entries []gqlcost.ReportEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	prefix := filepath.ToSlash(queries)
+	if got, want := strings.Join(ids, ","), prefix+"/large.graphql,"+prefix+"/small.graphql,abc"; got != want {
+		t.Fatalf("unexpected order: %s", got)
+	}
+
+	code, out, _ = runTest(t, "report", "-schema", schema, "-format", "csv", manifest)
+	if code != exitOK {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	exp := "id,operation,name,cost,exceeded,error\nabc,query,Medium,50,false,\n"
+	if out != exp {
+		t.Fatalf("unexpected CSV:\nwant=%s\ngot=%s", exp, out)
+	}
+}
@@ -0,0 +1,81 @@
+package gqlcost
+
+import (
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// ConnectionOptions provides options for Relay style connections.
+//
+// When it is enabled, a field which returns a connection type (a name ends
+// with "Connection" and has "edges" or "nodes" field) takes a multiplier
+// from its arguments (ex. "first" or "last"). The multiplier is applied only
+// to "edges" and "nodes" fields of the connection and their descendants, so
+// "pageInfo" and "totalCount" are priced as constant. A connection without
+// the arguments is priced as AnalysisOptions.DefaultListSize items, or one
+// item when it is not set.
+type ConnectionOptions struct {
+	// Enabled enables connection aware costing.
+	Enabled bool
+
+	// Multipliers enumerates names of arguments of connection fields to be
+	// used to calculate multiplier. Default is "first" and "last".
+	Multipliers []string
+
+	// Strategy is a strategy to combine values of Multipliers.
+	// Default is StrategyMax.
+	Strategy MultiplierStrategy
+
+	// Complexity is complexity of "edges" and "nodes" fields, which is used
+	// when CostMap doesn't provide cost for them. Default is 1.
+	Complexity int
+}
+
+var defaultConnectionMultipliers = []string{"first", "last"}
+
+// multiplier returns a multiplier from args. ok is false when args don't
+// have any arguments for the multiplier.
+func (co ConnectionOptions) multiplier(args map[string]interface{}) (n int, ok bool) {
+	c := Cost{
+		Multipliers: co.Multipliers,
+		Strategy:    co.Strategy,
+	}
+	if len(c.Multipliers) == 0 {
+		c.Multipliers = defaultConnectionMultipliers
+	}
+	if c.Strategy == "" {
+		c.Strategy = StrategyMax
+	}
+	for _, name := range c.Multipliers {
+		if args[name] != nil {
+			return c.getMultiplier(args), true
+		}
+	}
+	return 0, false
+}
+
+func (co ConnectionOptions) complexity() int {
+	if co.Complexity == 0 {
+		return 1
+	}
+	return co.Complexity
+}
+
+func isConnectionType(t graphql.Type) bool {
+	if nn, ok := t.(*graphql.NonNull); ok {
+		t = nn.OfType
+	}
+	obj, ok := t.(*graphql.Object)
+	if !ok || !strings.HasSuffix(obj.Name(), "Connection") {
+		return false
+	}
+	fm := obj.Fields()
+	_, hasEdges := fm["edges"]
+	_, hasNodes := fm["nodes"]
+	return hasEdges || hasNodes
+}
+
+func isConnectionEdges(name string) bool {
+	return name == "edges" || name == "nodes"
+}
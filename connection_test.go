@@ -0,0 +1,149 @@
+package gqlcost
+
+import (
+	"testing"
+)
+
+const connectionSDL = `
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: String
+}
+
+type User {
+	name: String
+	posts(first: Int, last: Int, after: String): PostConnection!
+}
+
+type UserConnection {
+	edges: [UserEdge]
+	nodes: [User]
+	pageInfo: PageInfo!
+	totalCount: Int
+}
+
+type UserEdge {
+	cursor: String
+	node: User
+}
+
+type Post {
+	title: String
+}
+
+type PostConnection {
+	edges: [PostEdge]
+	pageInfo: PageInfo!
+	totalCount: Int
+}
+
+type PostEdge {
+	node: Post
+}
+
+type Query {
+	users(first: Int, last: Int): UserConnection
+}
+`
+
+func TestConnection(t *testing.T) {
+	s, _, err := BuildSchema(connectionSDL)
+	if err != nil {
+		t.Fatalf("failed to build schema: %s", err)
+	}
+	for _, tc := range []struct {
+		name  string
+		query string
+		opts  AnalysisOptions
+		cost  int
+	}{
+		{
+			name: "edges",
+			query: `query {
+				users(first: 100) {
+					edges { node { posts(last: 50) { edges { node { title } } } } }
+					pageInfo { hasNextPage }
+					totalCount
+				}
+			}`,
+			opts: AnalysisOptions{Connection: ConnectionOptions{Enabled: true}},
+			// users.edges: 1*100, posts.edges: 1*100*50
+			cost: 5100,
+		},
+		{
+			name: "nodes via fragment",
+			query: `query {
+				users(first: 10) { ...userConn }
+			}
+			fragment userConn on UserConnection {
+				nodes { name }
+				totalCount
+			}`,
+			opts: AnalysisOptions{Connection: ConnectionOptions{Enabled: true, Complexity: 2}},
+			cost: 20,
+		},
+		{
+			name: "with CostMap",
+			query: `query {
+				users(first: 10, last: 20) {
+					edges { node { name posts { totalCount } } }
+					totalCount
+				}
+			}`,
+			opts: AnalysisOptions{
+				Connection: ConnectionOptions{Enabled: true},
+				CostMap: CostMap{
+					"User":           {Fields: FieldsCost{"name": {Complexity: 1, UseMultipliers: true}}},
+					"UserConnection": {Fields: FieldsCost{"totalCount": {Complexity: 5}}},
+				},
+			},
+			// users.edges: 1*20, name: 1*20, totalCount: 5
+			cost: 45,
+		},
+		{
+			name:  "disabled",
+			query: `query { users(first: 100) { edges { node { name } } } }`,
+			opts:  AnalysisOptions{DefaultCost: 1},
+			cost:  4,
+		},
+		{
+			name:  "no arguments",
+			query: `query { users { edges { node { name } } } }`,
+			opts:  AnalysisOptions{Connection: ConnectionOptions{Enabled: true}},
+			// priced as one item.
+			cost: 1,
+		},
+		{
+			name:  "explicit zero",
+			query: `query { users(first: 0) { edges { node { name } } totalCount } }`,
+			opts: AnalysisOptions{
+				Connection: ConnectionOptions{Enabled: true},
+				CostMap: CostMap{
+					"User":           {Fields: FieldsCost{"name": {Complexity: 1, UseMultipliers: true}}},
+					"UserConnection": {Fields: FieldsCost{"totalCount": {Complexity: 5}}},
+				},
+			},
+			// no edges, totalCount: 5
+			cost: 5,
+		},
+		{
+			name:  "default list size",
+			query: `query { users { edges { node { name } } } }`,
+			opts: AnalysisOptions{
+				Connection:      ConnectionOptions{Enabled: true},
+				DefaultListSize: 5,
+			},
+			cost: 5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Analyze(s, parseQuery(t, tc.query), tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if r.Cost != tc.cost {
+				t.Fatalf("wrong cost: want=%d got=%d", tc.cost, r.Cost)
+			}
+		})
+	}
+}
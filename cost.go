@@ -1,42 +1,141 @@
 package gqlcost
 
 import (
+	"sync"
+
+	"github.com/graphql-go/graphql"
 	"github.com/graphql-go/graphql/language/ast"
 )
 
+// MultiplierStrategy is a name of strategy to combine values of arguments
+// which are enumerated in Cost.Multipliers.
+type MultiplierStrategy string
+
+const (
+	// StrategySum sums values of all arguments. This is default.
+	StrategySum MultiplierStrategy = "sum"
+
+	// StrategyMax uses the largest value of arguments.
+	StrategyMax MultiplierStrategy = "max"
+
+	// StrategyProduct multiplies values of all arguments.
+	StrategyProduct MultiplierStrategy = "product"
+
+	// StrategyFirstPresent uses value of the first present argument in
+	// order of Cost.Multipliers. It fits Relay pagination where only one of
+	// "first" or "last" applies.
+	StrategyFirstPresent MultiplierStrategy = "firstPresent"
+)
+
+// StrategyFunc combines values of present arguments into a multiplier.
+// values are in order of Cost.Multipliers, and it is never empty.
+type StrategyFunc func(values []int) int
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[MultiplierStrategy]StrategyFunc{
+		StrategySum: func(values []int) int {
+			var sum int
+			for _, v := range values {
+				sum = addCost(sum, v)
+			}
+			return sum
+		},
+		StrategyMax: maxCost,
+		StrategyProduct: func(values []int) int {
+			prod := 1
+			for _, v := range values {
+				prod = mulCost(prod, v)
+			}
+			return prod
+		},
+		StrategyFirstPresent: func(values []int) int {
+			return values[0]
+		},
+	}
+)
+
+// RegisterStrategy registers a custom strategy with name. Registered
+// strategies are available for Cost.Strategy, and in files which are loaded
+// by LoadCostMap. It overrides a strategy which has same name.
+func RegisterStrategy(name MultiplierStrategy, fn StrategyFunc) {
+	strategiesMu.Lock()
+	strategies[name] = fn
+	strategiesMu.Unlock()
+}
+
+// unregisterStrategy removes a strategy which is registered by
+// RegisterStrategy, for tests.
+func unregisterStrategy(name MultiplierStrategy) {
+	strategiesMu.Lock()
+	delete(strategies, name)
+	strategiesMu.Unlock()
+}
+
+func (s MultiplierStrategy) strategyFunc() (StrategyFunc, bool) {
+	if s == "" {
+		s = StrategySum
+	}
+	strategiesMu.RLock()
+	fn, ok := strategies[s]
+	strategiesMu.RUnlock()
+	return fn, ok
+}
+
+func (s MultiplierStrategy) valid() bool {
+	_, ok := s.strategyFunc()
+	return ok
+}
+
 // Cost provides each cost value for type.field
 type Cost struct {
 	// UseMultipliers is flag to use multiplier.
 	// Multipliers and MultiplierFunc are referred only when this is true.
-	UseMultipliers bool `json:"useMultipliers,omitempty"`
+	UseMultipliers bool `json:"useMultipliers,omitempty" yaml:"useMultipliers,omitempty"`
 
 	// Complexity define default complexity of field or type.
-	Complexity int `json:"complexity,omitempty"`
+	Complexity int `json:"complexity,omitempty" yaml:"complexity,omitempty"`
 
 	// Multipliers enumerates name of arguments to be used to calculate
 	// multiplier.
-	Multipliers []string `json:"multipliers,omitempty"`
+	Multipliers []string `json:"multipliers,omitempty" yaml:"multipliers,omitempty"`
+
+	// Strategy is a strategy to combine values of Multipliers.
+	// StrategySum is used when it is empty.
+	Strategy MultiplierStrategy `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+
+	// AssumedSize is an assumed size of the list which is used as a
+	// multiplier, when the field returns a list and no multipliers are
+	// available. AnalysisOptions.DefaultListSize is used when it is zero.
+	AssumedSize int `json:"assumedSize,omitempty" yaml:"assumedSize,omitempty"`
 
 	// MultiplierFunc is for customizing multiplier calculation.
 	// When it available Multipliers is ignored.
-	MultiplierFunc func(map[string]interface{}) int
+	MultiplierFunc func(map[string]interface{}) int `json:"-" yaml:"-"`
 }
 
 func (c Cost) getMultiplier(args map[string]interface{}) int {
 	if c.MultiplierFunc != nil {
 		return c.MultiplierFunc(args)
 	}
-	var mul int
+	var values []int
 	for _, n := range c.Multipliers {
 		v, ok := args[n]
 		if !ok {
 			continue
 		}
 		if n, ok := toNumber(v); ok {
-			mul += n
+			values = append(values, n)
 		}
 	}
-	return mul
+	if len(values) == 0 {
+		return 0
+	}
+	fn, ok := c.Strategy.strategyFunc()
+	if !ok {
+		return 0
+	}
+	return fn(values)
 }
 
 // FieldsCost provides costs for each fields.
@@ -45,9 +144,9 @@ type FieldsCost map[string]Cost
 // TypeCost provides costs for a type and its fields.
 type TypeCost struct {
 	// Cost is cost of type itself
-	Cost *Cost
+	Cost *Cost `json:"cost,omitempty" yaml:"cost,omitempty"`
 	// Fields is costs for each fields.
-	Fields FieldsCost
+	Fields FieldsCost `json:"fields,omitempty" yaml:"fields,omitempty"`
 }
 
 // CostMap provides costs for type and fields.
@@ -67,3 +166,34 @@ func (m CostMap) getCost(contextTypeName string, fieldNode *ast.Field, fieldType
 	}
 	return nil
 }
+
+// AddWrappedTypes adds copies of entries for named types to the CostMap for
+// list and non-null types of fields in the schema (ex. "[User]!" for
+// "User"), when they are not defined. CostMap is looked up by names of
+// types as they are, so costs of fields of "User" are not applied to users
+// in a list without them.
+func (m CostMap) AddWrappedTypes(schema *graphql.Schema) {
+	for _, t := range schema.TypeMap() {
+		var fm graphql.FieldDefinitionMap
+		switch t := t.(type) {
+		case *graphql.Object:
+			fm = t.Fields()
+		case *graphql.Interface:
+			fm = t.Fields()
+		default:
+			continue
+		}
+		for _, f := range fm {
+			name, named := f.Type.Name(), typName(graphql.GetNamed(f.Type))
+			if name == named {
+				continue
+			}
+			if _, ok := m[name]; ok {
+				continue
+			}
+			if typeCost, ok := m[named]; ok {
+				m[name] = typeCost
+			}
+		}
+	}
+}
@@ -14,12 +14,44 @@ type costAnalysis struct {
 	opts AnalysisOptions
 	ctx  *graphql.ValidationContext
 	cost int
+
+	operations []OperationCost
+
+	// operationName is name of current operation.
+	operationName string
+
+	// variables is values of variables for current operation.
+	variables map[string]interface{}
+
+	// fieldCount is number of fields in current operation.
+	fieldCount int
+
+	// fieldsExceeded is true when fieldCount exceeds MaximumFields. The
+	// rest of current operation is not computed.
+	fieldsExceeded bool
+
+	// introspectionCost is cost of introspection fields in current
+	// operation.
+	introspectionCost int
+
+	// visitingFragments is names of fragments which are being expanded, to
+	// avoid infinite recursion by cycles of fragments.
+	visitingFragments map[string]struct{}
+
+	// fragmentResults is results of fragments in current operation, to
+	// avoid expanding a fragment again in the same context.
+	fragmentResults map[fragmentKey]fragmentResult
+
+	// aliasChecked is selection sets which aliases are checked, to avoid
+	// duplicated errors for fragments.
+	aliasChecked map[*ast.SelectionSet]struct{}
 }
 
 func newCostAnalysis(ctx *graphql.ValidationContext, opts AnalysisOptions) *costAnalysis {
 	ca := &costAnalysis{
-		opts: opts,
-		ctx:  ctx,
+		opts:              opts,
+		ctx:               ctx,
+		visitingFragments: map[string]struct{}{},
 	}
 	cr := ca.opts.ComplexityRange
 	if cr.Min != 0 && cr.Max != 0 && cr.Min > cr.Max {
@@ -41,46 +73,95 @@ func (ca *costAnalysis) visitorOptions() *visitor.VisitorOptions {
 
 func (ca *costAnalysis) opDefEnter(p visitor.VisitFuncParams) (string, interface{}) {
 	od, ok := p.Node.(*ast.OperationDefinition)
-	if !ok {
+	if !ok || !ca.isTarget(od) {
 		return visitor.ActionSkip, nil
 	}
+	var op *graphql.Object
 	switch od.GetOperation() {
 	case "query":
-		if op := ca.ctx.Schema().QueryType(); op != nil {
-			ca.cost += ca.computeNodeCost(od, op, nil)
-		}
-		return visitor.ActionNoChange, nil
+		op = ca.ctx.Schema().QueryType()
 	case "mutation":
-		if op := ca.ctx.Schema().MutationType(); op != nil {
-			ca.cost += ca.computeNodeCost(od, op, nil)
-		}
-		return visitor.ActionNoChange, nil
+		op = ca.ctx.Schema().MutationType()
 	case "subscription":
-		if op := ca.ctx.Schema().SubscriptionType(); op != nil {
-			ca.cost += ca.computeNodeCost(od, op, nil)
-		}
-		return visitor.ActionNoChange, nil
+		op = ca.ctx.Schema().SubscriptionType()
 	default:
 		return visitor.ActionSkip, nil
 	}
+	ca.variables = getVariableValues(ca.ctx.Schema(), od.VariableDefinitions, ca.opts.Valiables)
+	var (
+		cost int
+		bd   *CostBreakdown
+	)
+	if ca.opts.Breakdown || ca.opts.BreakdownInErrors {
+		bd = &CostBreakdown{}
+	}
+	ca.operationName = operationName(od)
+	ca.fieldCount = 0
+	ca.fieldsExceeded = false
+	ca.introspectionCost = 0
+	ca.fragmentResults = map[fragmentKey]fragmentResult{}
+	if op != nil {
+		cost = ca.computeNodeCost(od, op, nil, noConnection, bd)
+	}
+	cost = addCost(cost, ca.opts.operationOptions(od.GetOperation()).BaseCost)
+	ca.cost = addCost(ca.cost, cost)
+	oc := OperationCost{
+		Name:      ca.operationName,
+		Operation: od.GetOperation(),
+		Cost:      cost,
+	}
+	if bd != nil {
+		oc.Breakdown = bd.Children
+	}
+	ca.operations = append(ca.operations, oc)
+	return visitor.ActionNoChange, nil
 }
 
 func (ca *costAnalysis) opDefLeave(p visitor.VisitFuncParams) (string, interface{}) {
 	od, ok := p.Node.(*ast.OperationDefinition)
-	if !ok {
+	if !ok || !ca.isTarget(od) || len(ca.operations) == 0 {
 		return visitor.ActionSkip, nil
 	}
-	if ca.opts.MaximumCost > 0 && ca.cost > ca.opts.MaximumCost {
-		ca.reportError(fmt.Sprintf("The query exceeds the maximum cost of %d. Actual cost is %d", ca.opts.MaximumCost, ca.cost), []ast.Node{od})
+	oc := ca.operations[len(ca.operations)-1]
+	if max := ca.opts.operationOptions(oc.Operation).MaximumCost; max > 0 && oc.Cost > max {
+		err := &CostLimitError{
+			MaximumCost:   max,
+			ActualCost:    oc.Cost,
+			OperationName: oc.Name,
+		}
+		if ca.opts.BreakdownInErrors {
+			err.Breakdown = oc.Breakdown
+		}
+		ca.reportExtendedError(err, []ast.Node{od})
+	}
+	if max := ca.opts.Introspection.MaximumCost; max > 0 && ca.introspectionCost > max {
+		ca.reportExtendedError(&IntrospectionLimitError{
+			MaximumCost:   max,
+			ActualCost:    ca.introspectionCost,
+			OperationName: oc.Name,
+		}, []ast.Node{od})
 	}
 	//log.Printf("GraphQL COST=%d", ca.cost)
 	return visitor.ActionNoChange, nil
 }
 
+// isTarget checks the operation should be evaluated or not.
+func (ca *costAnalysis) isTarget(od *ast.OperationDefinition) bool {
+	return ca.opts.OperationName == "" || operationName(od) == ca.opts.OperationName
+}
+
 func (ca *costAnalysis) reportError(msg string, nodes []ast.Node) {
 	ca.ctx.ReportError(gqlerrors.NewError(msg, nodes, "", nil, []int{}, nil))
 }
 
+func (ca *costAnalysis) reportExtendedError(err gqlerrors.ExtendedError, nodes []ast.Node) {
+	reportExtendedError(ca.ctx, err, nodes)
+}
+
+func reportExtendedError(ctx *graphql.ValidationContext, err gqlerrors.ExtendedError, nodes []ast.Node) {
+	ctx.ReportError(gqlerrors.NewError(err.Error(), nodes, "", nil, []int{}, err))
+}
+
 func (ca *costAnalysis) getSectionSet(node ast.Node) (*ast.SelectionSet, bool) {
 	sel, ok := node.(ast.Selection)
 	if !ok {
@@ -113,20 +194,35 @@ func (ca *costAnalysis) getFieldDefinitionMap(typDef interface{}) graphql.FieldD
 	return graphql.FieldDefinitionMap{}
 }
 
-func (ca *costAnalysis) computeNodeCost(node ast.Node, typDef interface{}, parentMultipliers []int) int {
+// noConnection is conn of computeNodeCost for nodes which are not Relay
+// style connections.
+const noConnection = -1
+
+// computeNodeCost computes cost of the node. conn is a multiplier for
+// "edges" and "nodes" fields when the node is a Relay style connection,
+// otherwise it is noConnection.
+func (ca *costAnalysis) computeNodeCost(node ast.Node, typDef interface{}, parentMultipliers []int, conn int, bd *CostBreakdown) int {
 	selectionSet, ok := ca.getSectionSet(node)
 	if !ok {
 		return 0
 	}
 
 	fm := ca.getFieldDefinitionMap(typDef)
+	ca.checkAliases(node, selectionSet)
 
 	var (
-		total         int
-		fragmentCosts []int
+		total     int
+		fragments []fragmentCost
 	)
 
 	for _, iSelection := range selectionSet.Selections {
+		if ca.fieldsExceeded {
+			break
+		}
+		// skip fields and fragments which won't be executed.
+		if !shouldIncludeNode(selectionDirectives(iSelection), ca.variables) {
+			continue
+		}
 		nodeCost := ca.opts.DefaultCost
 		switch childNode := iSelection.(type) {
 
@@ -135,75 +231,359 @@ func (ca *costAnalysis) computeNodeCost(node ast.Node, typDef interface{}, paren
 				break
 			}
 			//log.Printf("field: %q %q %+v", childNode.Name.Value, typName(typDef), parentMultipliers)
+			ca.fieldCount++
+			if ca.checkFields(childNode) {
+				nodeCost = 0
+				break
+			}
+			child := bd.addField(childNode)
+			if isIntrospectionField(childNode.Name.Value) {
+				nodeCost = ca.opts.Introspection.cost(childNode.Name.Value, nodeCost)
+				ca.introspectionCost = addCost(ca.introspectionCost, nodeCost)
+				child.set(0, nil, nodeCost, nodeCost)
+				break
+			}
 			field, ok := fm[childNode.Name.Value]
 			if !ok {
+				child.set(0, nil, nodeCost, nodeCost)
 				break
 			}
 
+			var (
+				fieldArgs   map[string]interface{}
+				costMapArgs nodeCostConfig
+				fieldConn   = noConnection
+				isEdges     = conn != noConnection && isConnectionEdges(childNode.Name.Value)
+			)
+			if ca.opts.Connection.Enabled || len(ca.opts.CostMap) > 0 {
+				fieldArgs = getArgumentValues(field.Args, childNode.Arguments, ca.variables)
+				costMapArgs = ca.getArgsFromCostMap(childNode, typName(typDef), typName(field.Type), fieldArgs)
+			}
+			if ca.opts.Connection.Enabled && isConnectionType(field.Type) {
+				n, ok := ca.opts.Connection.multiplier(fieldArgs)
+				if !ok {
+					n = max(ca.assumedListSize(costMapArgs), 1)
+				}
+				fieldConn = max(n, 0)
+			}
+
 			// NOTE: graphql-go/graphql doesn't support directives in
 			// schema. So this package supports only used defined CostMap.
-			if len(ca.opts.CostMap) == 0 {
-				nodeCost += ca.computeNodeCost(childNode, field.Type, parentMultipliers)
+			if len(ca.opts.CostMap) == 0 && !isEdges {
+				// fields in lists of the assumed size cost DefaultCost for
+				// each item.
+				multipliers := copyInts(parentMultipliers)
+				if size := ca.assumedListSize(costMapArgs); size > 0 && isListType(field.Type) {
+					multipliers = append(multipliers, size)
+				}
+				for _, v := range multipliers {
+					nodeCost = mulCost(nodeCost, v)
+				}
+				childCost := ca.computeNodeCost(childNode, field.Type, multipliers, fieldConn, child)
+				if len(multipliers) > 0 {
+					child.set(0, multipliers, nodeCost, addCost(nodeCost, childCost))
+				} else {
+					child.set(0, nil, nodeCost, addCost(nodeCost, childCost))
+				}
+				nodeCost = addCost(nodeCost, childCost)
 				break
 			}
 
-			costMapArgs := ca.getArgsFromCostMap(childNode, typName(typDef), typName(field.Type),
-				getArgumentValues(field.Args, childNode.Arguments, ca.opts.Valiables))
+			switch {
+			case isEdges:
+				if !costMapArgs.found {
+					costMapArgs.complexity = ca.opts.Connection.complexity()
+				}
+				costMapArgs.useMultipliers = true
+				costMapArgs.multiplier = conn
+			case isListType(field.Type) && (!costMapArgs.useMultipliers || costMapArgs.multiplier == 0):
+				// assume size of the list without explicit multiplier.
+				if size := ca.assumedListSize(costMapArgs); size > 0 {
+					costMapArgs.useMultipliers = true
+					costMapArgs.multiplier = size
+				}
+			}
 
 			multipliers := copyInts(parentMultipliers)
 			nodeCost, multipliers = ca.computeCost(costMapArgs, multipliers)
-			nodeCost += ca.computeNodeCost(childNode, field.Type, multipliers)
+			if isEdges && conn == 0 {
+				// no items are returned for an explicit zero (ex. "first: 0").
+				nodeCost = 0
+				multipliers = append(multipliers, 0)
+			}
+			childCost := ca.computeNodeCost(childNode, field.Type, multipliers, fieldConn, child)
+			if costMapArgs.useMultipliers {
+				child.set(costMapArgs.complexity, multipliers, nodeCost, addCost(nodeCost, childCost))
+			} else {
+				child.set(costMapArgs.complexity, nil, nodeCost, addCost(nodeCost, childCost))
+			}
+			nodeCost = addCost(nodeCost, childCost)
 
 		case *ast.FragmentSpread:
 			fragName := ""
 			if childNode.Name != nil {
 				fragName = childNode.Name.Value
 			}
+			child := bd.addFragment("..." + fragName)
+			nodeCost = 0
+			if _, ok := ca.visitingFragments[fragName]; ok {
+				// cycles of fragments are reported by NoFragmentCycles rule.
+				break
+			}
 			fr := ca.ctx.Fragment(fragName)
 			if fr == nil || fr.TypeCondition == nil || fr.TypeCondition.Name == nil {
-				fragmentCosts = append(fragmentCosts, ca.opts.DefaultCost)
-				nodeCost = 0
+				fragments = append(fragments, fragmentCost{cost: ca.opts.DefaultCost})
+				child.set(0, nil, ca.opts.DefaultCost, ca.opts.DefaultCost)
 				break
 			}
 			fragType := ca.ctx.Schema().Type(fr.TypeCondition.Name.Value)
-			fragCost := ca.computeNodeCost(fr, fragType, parentMultipliers)
-			fragmentCosts = append(fragmentCosts, fragCost)
-			nodeCost = 0
+			key := newFragmentKey(fragName, parentMultipliers, conn, bd)
+			if r, ok := ca.fragmentResults[key]; ok {
+				// the fragment costs same in the same context, and its
+				// breakdown is omitted.
+				ca.fieldCount = addCost(ca.fieldCount, r.fields)
+				ca.introspectionCost = addCost(ca.introspectionCost, r.introspectionCost)
+				if ca.checkFields(childNode) {
+					break
+				}
+				fragments = append(fragments, fragmentCost{typ: fragType, cost: r.cost})
+				child.set(0, nil, 0, r.cost)
+				break
+			}
+			fields, introspectionCost := ca.fieldCount, ca.introspectionCost
+			ca.visitingFragments[fragName] = struct{}{}
+			fragCost := ca.computeNodeCost(fr, fragType, parentMultipliers, conn, child)
+			delete(ca.visitingFragments, fragName)
+			ca.fragmentResults[key] = fragmentResult{
+				cost:              fragCost,
+				fields:            ca.fieldCount - fields,
+				introspectionCost: ca.introspectionCost - introspectionCost,
+			}
+			fragments = append(fragments, fragmentCost{typ: fragType, cost: fragCost})
+			child.set(0, nil, 0, fragCost)
 
 		case *ast.InlineFragment:
+			nodeCost = 0
 			if childNode == nil {
-				fragmentCosts = append(fragmentCosts, ca.opts.DefaultCost)
-				nodeCost = 0
+				fragments = append(fragments, fragmentCost{cost: ca.opts.DefaultCost})
 				break
 			}
 			if childNode.TypeCondition == nil || childNode.TypeCondition.Name == nil {
-				fragCost := ca.computeNodeCost(childNode, typDef, parentMultipliers)
-				fragmentCosts = append(fragmentCosts, fragCost)
-				nodeCost = 0
+				child := bd.addFragment("...")
+				fragCost := ca.computeNodeCost(childNode, typDef, parentMultipliers, conn, child)
+				fragments = append(fragments, fragmentCost{cost: fragCost})
+				child.set(0, nil, 0, fragCost)
 				break
 			}
+			child := bd.addFragment("... on " + childNode.TypeCondition.Name.Value)
 			fragType := ca.ctx.Schema().Type(childNode.TypeCondition.Name.Value)
-			fragCost := ca.computeNodeCost(childNode, fragType, parentMultipliers)
-			fragmentCosts = append(fragmentCosts, fragCost)
-			nodeCost = 0
+			fragCost := ca.computeNodeCost(childNode, fragType, parentMultipliers, conn, child)
+			fragments = append(fragments, fragmentCost{typ: fragType, cost: fragCost})
+			child.set(0, nil, 0, fragCost)
 
 		default:
 			if n, ok := childNode.(ast.Node); ok {
-				nodeCost = ca.computeNodeCost(n, typDef, nil)
+				nodeCost = ca.computeNodeCost(n, typDef, nil, noConnection, bd)
 			}
 		}
 		if nodeCost > 0 {
-			total += nodeCost
+			total = addCost(total, nodeCost)
+		}
+	}
+
+	return addCost(total, ca.combineFragments(typDef, fragments))
+}
+
+// checkFields checks number of fields in current operation, and reports an
+// error when it exceeds MaximumFields first. The rest of the operation
+// shouldn't be computed when it returns true.
+func (ca *costAnalysis) checkFields(node ast.Node) bool {
+	max := ca.opts.MaximumFields
+	if max <= 0 || ca.fieldCount <= max {
+		return false
+	}
+	if !ca.fieldsExceeded {
+		ca.fieldsExceeded = true
+		ca.reportExtendedError(&FieldLimitError{
+			MaximumFields: max,
+			ActualFields:  ca.fieldCount,
+			OperationName: ca.operationName,
+		}, []ast.Node{node})
+	}
+	return true
+}
+
+// fragmentKey identifies a context where a fragment is spread. Cost of a
+// fragment depends on the product of multipliers of parents, and its
+// breakdown depends on the path.
+type fragmentKey struct {
+	name       string
+	multiplier int
+	conn       int
+	path       string
+}
+
+func newFragmentKey(name string, parentMultipliers []int, conn int, bd *CostBreakdown) fragmentKey {
+	key := fragmentKey{name: name, multiplier: 1, conn: conn}
+	for _, v := range parentMultipliers {
+		key.multiplier = mulCost(key.multiplier, v)
+	}
+	if bd != nil {
+		key.path = bd.Path
+	}
+	return key
+}
+
+// fragmentResult is a result of computing a fragment: its cost, and
+// increments of number of fields and cost of introspection fields.
+type fragmentResult struct {
+	cost              int
+	fields            int
+	introspectionCost int
+}
+
+// fragmentCost is cost of a fragment with its type condition. typ is nil
+// when the fragment has no type condition.
+type fragmentCost struct {
+	typ  graphql.Type
+	cost int
+}
+
+// combineFragments combines costs of fragments in a selection set on typDef.
+//
+// For an object type, costs of all applicable fragments are summed. For an
+// abstract type (interface or union), fragments without type condition or
+// on the type itself are shared by all possible types, and others are summed
+// for each possible type which they apply to. The cost is the shared cost
+// plus the maximum of the costs for possible types.
+func (ca *costAnalysis) combineFragments(typDef interface{}, fragments []fragmentCost) int {
+	if len(fragments) == 0 {
+		return 0
+	}
+	named := graphql.GetNamed(typDefToType(typDef))
+	var (
+		shared   int
+		abstract graphql.Abstract
+	)
+	switch t := named.(type) {
+	case *graphql.Interface:
+		abstract = t
+	case *graphql.Union:
+		abstract = t
+	}
+	if abstract == nil {
+		for _, f := range fragments {
+			if f.typ == nil || ca.fragmentApplies(f.typ, named) {
+				shared = addCost(shared, f.cost)
+			}
+		}
+		return shared
+	}
+	var perType []int
+	for _, obj := range ca.ctx.Schema().PossibleTypes(abstract) {
+		var c int
+		for _, f := range fragments {
+			if f.typ != nil && f.typ.Name() != abstract.Name() && ca.fragmentApplies(f.typ, obj) {
+				c = addCost(c, f.cost)
+			}
+		}
+		perType = append(perType, c)
+	}
+	for _, f := range fragments {
+		if f.typ == nil || f.typ.Name() == abstract.Name() {
+			shared = addCost(shared, f.cost)
 		}
 	}
+	return addCost(shared, maxCost(perType))
+}
+
+// fragmentApplies checks a fragment on cond applies to the type t or not.
+func (ca *costAnalysis) fragmentApplies(cond graphql.Type, t graphql.Named) bool {
+	if t == nil {
+		return false
+	}
+	if cond.Name() == typName(t) {
+		return true
+	}
+	obj, ok := t.(*graphql.Object)
+	if !ok {
+		return false
+	}
+	switch c := cond.(type) {
+	case *graphql.Interface:
+		return ca.ctx.Schema().IsPossibleType(c, obj)
+	case *graphql.Union:
+		return ca.ctx.Schema().IsPossibleType(c, obj)
+	}
+	return false
+}
+
+// checkAliases checks number of aliases in the selection set. Aliases in
+// fragments are counted in selection sets where they are merged into.
+func (ca *costAnalysis) checkAliases(node ast.Node, selectionSet *ast.SelectionSet) {
+	if ca.opts.MaximumAliases <= 0 {
+		return
+	}
+	switch node.(type) {
+	case *ast.FragmentDefinition, *ast.InlineFragment:
+		return
+	}
+	if _, ok := ca.aliasChecked[selectionSet]; ok {
+		return
+	}
+	if ca.aliasChecked == nil {
+		ca.aliasChecked = map[*ast.SelectionSet]struct{}{}
+	}
+	ca.aliasChecked[selectionSet] = struct{}{}
+	aliases := map[string]struct{}{}
+	ca.collectAliases(selectionSet, aliases, map[string]struct{}{})
+	n := len(aliases)
+	if n <= ca.opts.MaximumAliases {
+		return
+	}
+	ca.reportExtendedError(&AliasLimitError{
+		MaximumAliases: ca.opts.MaximumAliases,
+		ActualAliases:  n,
+		SelectionSet:   selectionSetName(node),
+	}, []ast.Node{node})
+}
 
-	return total + maxCost(fragmentCosts)
+// collectAliases collects aliases in the selection set, including ones in
+// fragments which are merged into it. visited is names of fragments which
+// are collected already.
+func (ca *costAnalysis) collectAliases(selectionSet *ast.SelectionSet, aliases, visited map[string]struct{}) {
+	if selectionSet == nil {
+		return
+	}
+	for _, iSelection := range selectionSet.Selections {
+		switch sel := iSelection.(type) {
+		case *ast.Field:
+			if sel.Alias != nil && sel.Alias.Value != "" {
+				aliases[sel.Alias.Value] = struct{}{}
+			}
+		case *ast.InlineFragment:
+			ca.collectAliases(sel.SelectionSet, aliases, visited)
+		case *ast.FragmentSpread:
+			if sel.Name == nil {
+				continue
+			}
+			if _, ok := visited[sel.Name.Value]; ok {
+				continue
+			}
+			visited[sel.Name.Value] = struct{}{}
+			if fr := ca.ctx.Fragment(sel.Name.Value); fr != nil {
+				ca.collectAliases(fr.SelectionSet, aliases, visited)
+			}
+		}
+	}
 }
 
 type nodeCostConfig struct {
+	found          bool
 	useMultipliers bool
 	complexity     int
 	multiplier     int
+	assumedSize    int
 }
 
 func (ca *costAnalysis) getArgsFromCostMap(node *ast.Field, parentTyp, fieldType string, fieldArgs map[string]interface{}) (ncc nodeCostConfig) {
@@ -212,10 +592,19 @@ func (ca *costAnalysis) getArgsFromCostMap(node *ast.Field, parentTyp, fieldType
 		return nodeCostConfig{}
 	}
 	return nodeCostConfig{
+		found:          true,
 		useMultipliers: cost.UseMultipliers,
 		complexity:     cost.Complexity,
 		multiplier:     cost.getMultiplier(fieldArgs),
+		assumedSize:    cost.AssumedSize,
+	}
+}
+
+func (ca *costAnalysis) assumedListSize(ncc nodeCostConfig) int {
+	if ncc.assumedSize > 0 {
+		return ncc.assumedSize
 	}
+	return ca.opts.DefaultListSize
 }
 
 func (ca *costAnalysis) computeCost(ncc nodeCostConfig, parentMultipliers []int) (int, []int) {
@@ -234,7 +623,7 @@ func (ca *costAnalysis) computeCost(ncc nodeCostConfig, parentMultipliers []int)
 
 	acc := ncc.complexity
 	for _, v := range parentMultipliers {
-		acc *= v
+		acc = mulCost(acc, v)
 	}
 
 	return acc, parentMultipliers
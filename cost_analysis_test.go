@@ -1,6 +1,9 @@
 package gqlcost
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/graphql-go/graphql"
@@ -200,6 +203,9 @@ func init() {
 					"last": &graphql.ArgumentConfig{
 						Type: graphql.Int,
 					},
+					"depth": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
 					"list": &graphql.ArgumentConfig{
 						Type: graphql.NewList(graphql.String),
 					},
@@ -658,6 +664,19 @@ func TestInlineFragment_NoTypeCondition(t *testing.T) {
 	}, 6)
 }
 
+func TestFragmentCycle(t *testing.T) {
+	testCost(t, `
+		query { first(limit: 10) { ...a } }
+		fragment a on First { second(limit: 10) ...b }
+		fragment b on First { ...a }`,
+		AnalysisOptions{
+			CostMap: CostMap{
+				"Query": {Fields: FieldsCost{"first": limitCost(2)}},
+				"First": {Fields: FieldsCost{"second": limitCost(5)}},
+			},
+		}, 520)
+}
+
 func TestMultiplierFunc(t *testing.T) {
 	testCost(t, `query { customCostWithResolver(limit: 10) }`, AnalysisOptions{
 		MaximumCost: 100,
@@ -675,3 +694,379 @@ func TestMultiplierFunc(t *testing.T) {
 		},
 	}, 70)
 }
+
+func TestVariables(t *testing.T) {
+	testCost(t, `
+		query($limit: Int) {
+			customCostWithResolver(limit: $limit)
+		}`,
+		AnalysisOptions{
+			MaximumCost: 100,
+			Valiables:   map[string]interface{}{"limit": 10},
+			CostMap: CostMap{
+				"Query": {Fields: FieldsCost{
+					"customCostWithResolver": limitCost(4),
+				}},
+			},
+		}, 40)
+}
+
+func TestVariables_Default(t *testing.T) {
+	testCost(t, `
+		query($limit: Int = 5) {
+			customCostWithResolver(limit: $limit)
+		}`,
+		AnalysisOptions{
+			MaximumCost: 100,
+			CostMap: CostMap{
+				"Query": {Fields: FieldsCost{
+					"customCostWithResolver": limitCost(4),
+				}},
+			},
+		}, 20)
+}
+
+func TestMultipleOperations(t *testing.T) {
+	opts := AnalysisOptions{
+		MaximumCost: 10,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+		},
+	}
+	query := `
+		query A { customCost }
+		query B { customCost }
+		query C { customCost customCost2: customCost }`
+	ca := testErrs(t, query, opts, "The query exceeds the maximum cost of 10. Actual cost is 16")
+	if ca.cost != 32 {
+		t.Fatalf("wrong total cost: want=%d got=%d", 32, ca.cost)
+	}
+
+	opts.OperationName = "B"
+	ca = testCost(t, query, opts, 8)
+	if len(ca.operations) != 1 || ca.operations[0].Name != "B" {
+		t.Fatalf("unexpected operations: %+v", ca.operations)
+	}
+}
+
+func TestNamedTypeCost(t *testing.T) {
+	// "InnerType" isn't used for fields of "[InnerType]!".
+	testCost(t, `query { innerList { name } }`, AnalysisOptions{
+		MaximumCost: 100,
+		CostMap: CostMap{
+			"Query": {
+				Fields: FieldsCost{
+					"innerList": {Complexity: 1},
+				},
+			},
+			"InnerType": {
+				Fields: FieldsCost{
+					"name": {Complexity: 10},
+				},
+			},
+		},
+	}, 1)
+}
+
+func TestSeveralMultipliers_Max(t *testing.T) {
+	testCost(t, `query { severalMultipliers(first: 10, last: 4) }`,
+		AnalysisOptions{
+			MaximumCost: 1000,
+			CostMap: CostMap{"Query": {Fields: FieldsCost{
+				"severalMultipliers": {
+					Multipliers:    []string{"first", "last"},
+					UseMultipliers: true,
+					Complexity:     4,
+					Strategy:       StrategyMax,
+				},
+			}}},
+		}, 40)
+}
+
+func TestSeveralMultipliers_ProductOverflow(t *testing.T) {
+	testErrs(t, `query { severalMultipliers(first: 2097152, last: 2097152, depth: 2097152) }`,
+		AnalysisOptions{
+			MaximumCost: 1000,
+			CostMap: CostMap{"Query": {Fields: FieldsCost{
+				"severalMultipliers": {
+					Multipliers:    []string{"first", "last", "depth"},
+					UseMultipliers: true,
+					Complexity:     1,
+					Strategy:       StrategyProduct,
+				},
+			}}},
+		}, `The query exceeds the maximum cost of 1000. Actual cost is 9223372036854775807`)
+}
+
+func TestDefaultListSize(t *testing.T) {
+	costMap := func(innerList Cost) CostMap {
+		return CostMap{
+			"Query": {Fields: FieldsCost{"innerList": innerList}},
+			"[InnerType]!": {Fields: FieldsCost{
+				"name": {Complexity: 1, UseMultipliers: true},
+			}},
+		}
+	}
+	query := `query { innerList { name } }`
+	testCost(t, query, AnalysisOptions{
+		CostMap: costMap(Cost{Complexity: 1}),
+	}, 2)
+	testCost(t, query, AnalysisOptions{
+		DefaultListSize: 10,
+		CostMap:         costMap(Cost{Complexity: 1}),
+	}, 20)
+	testCost(t, query, AnalysisOptions{
+		DefaultListSize: 10,
+		CostMap:         costMap(Cost{Complexity: 1, AssumedSize: 3}),
+	}, 6)
+	// not applied to non-list fields.
+	testCost(t, `query { customCost }`, AnalysisOptions{
+		DefaultListSize: 10,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+		},
+	}, 8)
+	// fields in the list cost DefaultCost for each item without CostMap.
+	testCost(t, query, AnalysisOptions{
+		DefaultCost:     1,
+		DefaultListSize: 100,
+	}, 200)
+	testCost(t, `query { inner { name } }`, AnalysisOptions{
+		DefaultCost:     1,
+		DefaultListSize: 100,
+	}, 2)
+}
+
+func TestMaximumFields(t *testing.T) {
+	query := `
+		query Foo {
+			first {
+				string
+				...frag
+				... on First { int }
+			}
+		}
+		fragment frag on First { string int }`
+	testCost(t, query, AnalysisOptions{MaximumFields: 5}, 0)
+	testErrs(t, query, AnalysisOptions{MaximumFields: 4},
+		"The query exceeds the maximum number of fields of 4. Actual number is 5")
+}
+
+// fanOutQuery returns a query which has 2^n+1 fields after expanding
+// fragments.
+func fanOutQuery(n int) string {
+	var b strings.Builder
+	b.WriteString("query { first { ...f0 } }\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "fragment f%d on First { ...f%d ...f%d }\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, "fragment f%d on First { string }\n", n)
+	return b.String()
+}
+
+func TestMaximumFields_FanOut(t *testing.T) {
+	testErrs(t, fanOutQuery(40), AnalysisOptions{MaximumFields: 100},
+		"The query exceeds the maximum number of fields of 100. Actual number is 129")
+}
+
+func TestFragmentFanOut(t *testing.T) {
+	// fragments are expanded once for each context.
+	testCost(t, fanOutQuery(24), AnalysisOptions{DefaultCost: 1}, 1<<24+1)
+	ca := testCost(t, fanOutQuery(24), AnalysisOptions{DefaultCost: 1, Breakdown: true}, 1<<24+1)
+	if b := ca.operations[0].Breakdown; len(b) != 1 || b[0].Total != 1<<24+1 {
+		t.Fatalf("unexpected breakdown: %+v", b)
+	}
+	// cost doesn't overflow.
+	testCost(t, fanOutQuery(100), AnalysisOptions{DefaultCost: 1}, math.MaxInt)
+	testCost(t, fanOutQuery(100), AnalysisOptions{
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{"first": limitCost(1)}},
+			"First": {Fields: FieldsCost{"string": {Complexity: 1, UseMultipliers: true}}},
+		},
+	}, math.MaxInt)
+}
+
+func TestMaximumAliases(t *testing.T) {
+	query := `
+		query {
+			a1: customCost
+			first {
+				b1: string
+				b2: string
+				b3: string
+			}
+			second: first { ...frag ...frag }
+		}
+		fragment frag on First { c1: int c2: int c3: int }`
+	testCost(t, query, AnalysisOptions{MaximumAliases: 3}, 0)
+	testErrs(t, query, AnalysisOptions{MaximumAliases: 2},
+		`The selection set "first" exceeds the maximum number of aliases of 2. Actual number is 3`,
+		`The selection set "second" exceeds the maximum number of aliases of 2. Actual number is 3`)
+}
+
+func TestMaximumAliases_Fragments(t *testing.T) {
+	// aliases are counted after merging fragments.
+	query := `
+		query {
+			first {
+				...frag1
+				... on First { b1: string b2: string }
+			}
+		}
+		fragment frag1 on First { a1: int a2: int ...frag2 }
+		fragment frag2 on First { a1: int a3: int }`
+	testCost(t, query, AnalysisOptions{MaximumAliases: 5}, 0)
+	testErrs(t, query, AnalysisOptions{MaximumAliases: 4},
+		`The selection set "first" exceeds the maximum number of aliases of 4. Actual number is 5`)
+}
+
+func TestAbstractType(t *testing.T) {
+	costMap := CostMap{
+		"Query":          {Fields: FieldsCost{"first": limitCost(2)}},
+		"BasicInterface": {Fields: FieldsCost{"int": {Complexity: 7}}},
+		"First": {Fields: FieldsCost{
+			"firstOrSecond": limitCost(3),
+			"second":        limitCost(5),
+			"anotherSecond": limitCost(5),
+		}},
+		"Second": {Fields: FieldsCost{"third": limitCost(6)}},
+	}
+	for _, tc := range []struct {
+		name  string
+		query string
+		cost  int
+	}{
+		{
+			name: "fragments on same type are summed",
+			query: `query {
+				first(limit: 10) {
+					firstOrSecond(limit: 10) {
+						... on First { second(limit: 10) }
+						... on First { s2: second(limit: 10) }
+						... on Second { third(limit: 10) }
+					}
+				}
+			}`,
+			cost: 10320,
+		},
+		{
+			name: "fragment on interface applies to possible types",
+			query: `query {
+				first(limit: 10) {
+					firstOrSecond(limit: 10) {
+						... on BasicInterface { int }
+						... on Second { third(limit: 10) }
+					}
+				}
+			}`,
+			cost: 6327,
+		},
+		{
+			name: "nested unions",
+			query: `query {
+				first(limit: 10) {
+					firstOrSecond(limit: 10) {
+						... on First {
+							firstOrSecond(limit: 2) {
+								... on First { second(limit: 1) }
+								... on Second { third(limit: 10) }
+							}
+						}
+						... on Second { third(limit: 10) }
+					}
+				}
+			}`,
+			cost: 12920,
+		},
+		{
+			name: "shared fields of interface",
+			query: `query {
+				first(limit: 10) {
+					basicInterface {
+						int
+						... on BasicInterface { int2: int }
+						...secondFields
+					}
+				}
+			}
+			fragment secondFields on Second { third(limit: 10) }`,
+			cost: 634,
+		},
+		{
+			name: "fragments on object type are summed",
+			query: `query {
+				first(limit: 10) { ...a ...b }
+			}
+			fragment a on First { second(limit: 10) }
+			fragment b on First { anotherSecond(limit: 10) }`,
+			cost: 1020,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testCost(t, tc.query, AnalysisOptions{CostMap: costMap}, tc.cost)
+		})
+	}
+}
+
+func TestSkipInclude(t *testing.T) {
+	costMap := CostMap{
+		"Query": {Fields: FieldsCost{
+			"customCost": {Complexity: 8},
+			"first":      limitCost(2),
+		}},
+		"First": {Fields: FieldsCost{"second": limitCost(5)}},
+	}
+	for _, tc := range []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		cost      int
+	}{
+		{
+			name:  "skip literal",
+			query: `query { customCost first(limit: 10) @skip(if: true) { second(limit: 10) } }`,
+			cost:  8,
+		},
+		{
+			name:  "include literal",
+			query: `query { customCost first(limit: 10) @include(if: true) { second(limit: 10) } }`,
+			cost:  528,
+		},
+		{
+			name:      "include variable",
+			query:     `query($flag: Boolean!) { customCost first(limit: 10) @include(if: $flag) { second(limit: 10) } }`,
+			variables: map[string]interface{}{"flag": false},
+			cost:      8,
+		},
+		{
+			name:  "include variable default",
+			query: `query($flag: Boolean = false) { customCost @include(if: $flag) }`,
+			cost:  0,
+		},
+		{
+			name:      "skip precedes include",
+			query:     `query($flag: Boolean!) { customCost @skip(if: $flag) @include(if: true) }`,
+			variables: map[string]interface{}{"flag": true},
+			cost:      0,
+		},
+		{
+			name:      "fragment spread",
+			query:     `query($flag: Boolean!) { first(limit: 10) { ...a @skip(if: $flag) } } fragment a on First { second(limit: 10) }`,
+			variables: map[string]interface{}{"flag": true},
+			cost:      20,
+		},
+		{
+			name:      "inline fragment",
+			query:     `query($flag: Boolean!) { first(limit: 10) { ... @include(if: $flag) { second(limit: 10) } } }`,
+			variables: map[string]interface{}{"flag": true},
+			cost:      520,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testCost(t, tc.query, AnalysisOptions{
+				Valiables: tc.variables,
+				CostMap:   costMap,
+			}, tc.cost)
+		})
+	}
+}
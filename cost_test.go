@@ -0,0 +1,59 @@
+package gqlcost
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCostGetMultiplier(t *testing.T) {
+	RegisterStrategy("min", func(values []int) int {
+		m := values[0]
+		for _, v := range values[1:] {
+			if v < m {
+				m = v
+			}
+		}
+		return m
+	})
+	t.Cleanup(func() { unregisterStrategy("min") })
+	args := map[string]interface{}{"first": 10, "last": 4, "depth": 3, "huge": math.MaxInt}
+	for _, tc := range []struct {
+		strategy    MultiplierStrategy
+		multipliers []string
+		want        int
+	}{
+		{"", []string{"first", "last"}, 14},
+		{StrategySum, []string{"first", "last"}, 14},
+		{StrategyMax, []string{"last", "first"}, 10},
+		{StrategyProduct, []string{"first", "depth"}, 30},
+		{StrategyFirstPresent, []string{"before", "last", "first"}, 4},
+		{"min", []string{"first", "last", "depth"}, 3},
+		{StrategyProduct, []string{"none"}, 0},
+		{StrategySum, []string{"huge", "first"}, math.MaxInt},
+		{StrategyProduct, []string{"huge", "first"}, math.MaxInt},
+		{"unknown", []string{"first"}, 0},
+	} {
+		c := Cost{Multipliers: tc.multipliers, Strategy: tc.strategy}
+		if got := c.getMultiplier(args); got != tc.want {
+			t.Errorf("getMultiplier with %q %v: want=%d got=%d", tc.strategy, tc.multipliers, tc.want, got)
+		}
+	}
+}
+
+func TestCostMap_AddWrappedTypes(t *testing.T) {
+	name := Cost{Complexity: 1}
+	m := CostMap{
+		"InnerType":  {Fields: FieldsCost{"name": name}},
+		"InnerType!": {Fields: FieldsCost{"name": {Complexity: 2}}},
+	}
+	m.AddWrappedTypes(schema)
+	exp := CostMap{
+		"InnerType":    {Fields: FieldsCost{"name": name}},
+		"InnerType!":   {Fields: FieldsCost{"name": {Complexity: 2}}},
+		"[InnerType]!": {Fields: FieldsCost{"name": name}},
+	}
+	if !reflect.DeepEqual(m, exp) {
+		t.Fatalf("unexpected CostMap:\nwant=%+v\ngot=%+v", exp, m)
+	}
+}
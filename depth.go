@@ -0,0 +1,118 @@
+package gqlcost
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/kinds"
+	"github.com/graphql-go/graphql/language/visitor"
+)
+
+// DepthOptions provides options for depth limiting.
+type DepthOptions struct {
+	// MaximumDepth is the maximum depth of each operation. Top level fields
+	// have depth 1. No limits when it is zero.
+	MaximumDepth int
+
+	// OperationName is name of the operation to be evaluated. When it is
+	// empty, all operations in the document are evaluated.
+	OperationName string
+
+	// IgnoreIntrospection ignores introspection fields (ex. "__schema",
+	// "__type" and "__typename") and their descendants.
+	IgnoreIntrospection bool
+}
+
+// DepthRule provides depth limiting rule (function)
+func DepthRule(opts DepthOptions) graphql.ValidationRuleFn {
+	return func(ctx *graphql.ValidationContext) *graphql.ValidationRuleInstance {
+		da := &depthAnalysis{opts: opts, ctx: ctx, depths: map[string]int{}}
+		return &graphql.ValidationRuleInstance{VisitorOpts: da.visitorOptions()}
+	}
+}
+
+type depthAnalysis struct {
+	opts DepthOptions
+	ctx  *graphql.ValidationContext
+
+	// depths is depths of fragments which are computed already, to expand
+	// each fragment only once.
+	depths map[string]int
+}
+
+func (da *depthAnalysis) visitorOptions() *visitor.VisitorOptions {
+	return &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.OperationDefinition: {
+				Kind: da.opDef,
+			},
+		},
+	}
+}
+
+func (da *depthAnalysis) opDef(p visitor.VisitFuncParams) (string, interface{}) {
+	od, ok := p.Node.(*ast.OperationDefinition)
+	if !ok {
+		return visitor.ActionSkip, nil
+	}
+	if da.opts.OperationName != "" && operationName(od) != da.opts.OperationName {
+		return visitor.ActionSkip, nil
+	}
+	depth := da.computeDepth(od.SelectionSet, map[string]struct{}{})
+	if da.opts.MaximumDepth > 0 && depth > da.opts.MaximumDepth {
+		reportExtendedError(da.ctx, &DepthLimitError{
+			MaximumDepth:  da.opts.MaximumDepth,
+			ActualDepth:   depth,
+			OperationName: operationName(od),
+		}, []ast.Node{od})
+	}
+	return visitor.ActionSkip, nil
+}
+
+// computeDepth computes depth of the selection set. visited is names of
+// fragments which are being expanded, to avoid infinite recursion by cycles
+// of fragments.
+func (da *depthAnalysis) computeDepth(selectionSet *ast.SelectionSet, visited map[string]struct{}) int {
+	if selectionSet == nil {
+		return 0
+	}
+	var depth int
+	for _, iSelection := range selectionSet.Selections {
+		var d int
+		switch sel := iSelection.(type) {
+		case *ast.Field:
+			if sel.Name == nil {
+				continue
+			}
+			if da.opts.IgnoreIntrospection && isIntrospectionField(sel.Name.Value) {
+				continue
+			}
+			d = 1 + da.computeDepth(sel.SelectionSet, visited)
+		case *ast.FragmentSpread:
+			if sel.Name == nil {
+				continue
+			}
+			name := sel.Name.Value
+			if _, ok := visited[name]; ok {
+				continue
+			}
+			if fd, ok := da.depths[name]; ok {
+				d = fd
+				break
+			}
+			fr := da.ctx.Fragment(name)
+			if fr == nil {
+				continue
+			}
+			visited[name] = struct{}{}
+			d = da.computeDepth(fr.SelectionSet, visited)
+			delete(visited, name)
+			da.depths[name] = d
+		case *ast.InlineFragment:
+			d = da.computeDepth(sel.SelectionSet, visited)
+		}
+		if d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
@@ -0,0 +1,76 @@
+package gqlcost
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func testDepth(t *testing.T, query string, opts DepthOptions, expErrs ...string) {
+	t.Helper()
+	astDoc := parseQuery(t, query)
+	vr := graphql.ValidateDocument(schema, astDoc, []graphql.ValidationRuleFn{DepthRule(opts)})
+	if len(vr.Errors) != len(expErrs) {
+		t.Fatalf("unexpected number of errors: want=%d got=%d %+v", len(expErrs), len(vr.Errors), vr.Errors)
+	}
+	for i, err := range vr.Errors {
+		if err.Message != expErrs[i] {
+			t.Fatalf("%d error mismatch:\nwant=%s\ngot=%s", i, expErrs[i], err.Message)
+		}
+	}
+}
+
+func TestDepth(t *testing.T) {
+	query := `
+		query A {
+			first {
+				second { int }
+				...frag
+			}
+		}
+		fragment frag on First {
+			basicInterface {
+				... on Second { third }
+			}
+		}
+		query B { defaultCost }`
+	testDepth(t, query, DepthOptions{MaximumDepth: 3})
+	testDepth(t, query, DepthOptions{MaximumDepth: 2},
+		"The query exceeds the maximum depth of 2. Actual depth is 3")
+	testDepth(t, query, DepthOptions{MaximumDepth: 2, OperationName: "B"})
+	testDepth(t, query, DepthOptions{})
+}
+
+func TestDepth_Introspection(t *testing.T) {
+	query := `query { __schema { types { fields { name } } } }`
+	testDepth(t, query, DepthOptions{MaximumDepth: 2},
+		"The query exceeds the maximum depth of 2. Actual depth is 4")
+	testDepth(t, query, DepthOptions{MaximumDepth: 2, IgnoreIntrospection: true})
+}
+
+func TestDepth_FragmentCycle(t *testing.T) {
+	query := `
+		query { first { ...a } }
+		fragment a on First { second { int } ...b }
+		fragment b on First { ...a }`
+	testDepth(t, query, DepthOptions{MaximumDepth: 1},
+		"The query exceeds the maximum depth of 1. Actual depth is 3")
+}
+
+func TestDepth_FragmentFanOut(t *testing.T) {
+	// fragments are expanded once.
+	testDepth(t, fanOutQuery(100), DepthOptions{MaximumDepth: 1},
+		"The query exceeds the maximum depth of 1. Actual depth is 2")
+}
+
+func TestDepthLimitError(t *testing.T) {
+	astDoc := parseQuery(t, `query Foo { first { second { int } } }`)
+	vr := graphql.ValidateDocument(schema, astDoc, []graphql.ValidationRuleFn{DepthRule(DepthOptions{MaximumDepth: 1})})
+	if len(vr.Errors) != 1 {
+		t.Fatalf("one error expected: %+v", vr.Errors)
+	}
+	ext := vr.Errors[0].Extensions
+	if ext["code"] != CodeDepthLimitExceeded || ext["maximumDepth"] != 1 || ext["actualDepth"] != 3 || ext["operationName"] != "Foo" {
+		t.Fatalf("unexpected extensions: %+v", ext)
+	}
+}
@@ -0,0 +1,73 @@
+package gqlcost
+
+import "github.com/graphql-go/graphql"
+
+// DiffEntry provides difference of cost of an operation between two
+// options, ex. before and after changes of CostMap.
+type DiffEntry struct {
+	// ID is ID of the query which contains the operation.
+	ID string `json:"id"`
+
+	// Name is name of the operation. It is empty for anonymous operation.
+	Name string `json:"name,omitempty"`
+
+	// Operation is type of the operation: "query", "mutation" or
+	// "subscription".
+	Operation string `json:"operation,omitempty"`
+
+	// OldCost and NewCost are costs of the operation with each options.
+	OldCost int `json:"oldCost"`
+	NewCost int `json:"newCost"`
+
+	// Delta is NewCost - OldCost.
+	Delta int `json:"delta"`
+
+	// OldExceeded and NewExceeded are true when the cost exceeds the
+	// maximum cost of each options.
+	OldExceeded bool `json:"oldExceeded"`
+	NewExceeded bool `json:"newExceeded"`
+
+	// Error is an error of the query, ex. syntax errors.
+	Error string `json:"error,omitempty"`
+}
+
+// Rejected returns true when the operation newly exceeds the maximum cost.
+func (e DiffEntry) Rejected() bool {
+	return !e.OldExceeded && e.NewExceeded
+}
+
+// Accepted returns true when the operation no longer exceeds the maximum
+// cost.
+func (e DiffEntry) Accepted() bool {
+	return e.OldExceeded && !e.NewExceeded
+}
+
+// Diff computes cost of each operation in queries with two options, and
+// reports differences of them.
+func Diff(schema *graphql.Schema, queries []PersistedQuery, oldOpts, newOpts AnalysisOptions) []DiffEntry {
+	var entries []DiffEntry
+	for _, q := range queries {
+		qs := []PersistedQuery{q}
+		olds := Report(schema, qs, oldOpts)
+		news := Report(schema, qs, newOpts)
+		for i := 0; i < len(olds) && i < len(news); i++ {
+			o, n := olds[i], news[i]
+			e := DiffEntry{
+				ID:          n.ID,
+				Name:        n.Name,
+				Operation:   n.Operation,
+				OldCost:     o.Cost,
+				NewCost:     n.Cost,
+				Delta:       n.Cost - o.Cost,
+				OldExceeded: o.Exceeded,
+				NewExceeded: n.Exceeded,
+				Error:       n.Error,
+			}
+			if e.Error == "" {
+				e.Error = o.Error
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
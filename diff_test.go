@@ -0,0 +1,54 @@
+package gqlcost
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	queries := []PersistedQuery{
+		{ID: "q1", Query: `query A { customCost } query B { defaultCost }`},
+		{ID: "q2", Query: `query C { first(limit: 3) { second(limit: 2) { int } } }`},
+		{ID: "q3", Query: `query {`},
+	}
+	oldOpts := AnalysisOptions{
+		MaximumCost: 10,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{
+				"customCost": {Complexity: 8},
+				"first":      limitCost(4),
+			}},
+		},
+	}
+	newOpts := AnalysisOptions{
+		MaximumCost: 10,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{
+				"customCost": {Complexity: 12},
+				"first":      limitCost(1),
+			}},
+			"First": {Fields: FieldsCost{"second": limitCost(1)}},
+		},
+	}
+	entries := Diff(schema, queries, oldOpts, newOpts)
+	if len(entries) != 4 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	exp := []DiffEntry{
+		{ID: "q1", Name: "A", Operation: "query", OldCost: 8, NewCost: 12, Delta: 4, NewExceeded: true},
+		{ID: "q1", Name: "B", Operation: "query"},
+		{ID: "q2", Name: "C", Operation: "query", OldCost: 12, NewCost: 9, Delta: -3, OldExceeded: true},
+	}
+	if !reflect.DeepEqual(entries[:3], exp) {
+		t.Fatalf("unexpected entries:\nwant=%+v\ngot=%+v", exp, entries[:3])
+	}
+	if !entries[0].Rejected() || entries[0].Accepted() {
+		t.Errorf("q1/A should be rejected: %+v", entries[0])
+	}
+	if entries[2].Rejected() || !entries[2].Accepted() {
+		t.Errorf("q2/C should be accepted: %+v", entries[2])
+	}
+	if entries[3].Error == "" {
+		t.Errorf("error expected: %+v", entries[3])
+	}
+}
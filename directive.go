@@ -0,0 +1,154 @@
+package gqlcost
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// CostDirectiveName is name of the directive to provide cost in SDL.
+//
+//	directive @cost(
+//	  complexity: Int
+//	  multipliers: [String]
+//	  useMultipliers: Boolean
+//	) on OBJECT | FIELD_DEFINITION
+const CostDirectiveName = "cost"
+
+// CostMapFromSDL parses SDL and extracts values of @cost directives on types
+// and fields as CostMap.
+func CostMapFromSDL(sdl string) (CostMap, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: sdl})
+	if err != nil {
+		return nil, err
+	}
+	return CostMapFromDocument(doc)
+}
+
+// CostMapFromDocument extracts values of @cost directives on types and fields
+// in the document as CostMap.
+//
+// useMultipliers is true when it is omitted, as same as
+// graphql-cost-analysis.
+func CostMapFromDocument(doc *ast.Document) (CostMap, error) {
+	m := CostMap{}
+	for _, def := range doc.Definitions {
+		var (
+			name       *ast.Name
+			directives []*ast.Directive
+			fields     []*ast.FieldDefinition
+		)
+		switch def := def.(type) {
+		case *ast.ObjectDefinition:
+			name, directives, fields = def.Name, def.Directives, def.Fields
+		case *ast.InterfaceDefinition:
+			name, directives, fields = def.Name, def.Directives, def.Fields
+		case *ast.TypeExtensionDefinition:
+			if def.Definition == nil {
+				continue
+			}
+			name, directives, fields = def.Definition.Name, def.Definition.Directives, def.Definition.Fields
+		default:
+			continue
+		}
+		if name == nil {
+			continue
+		}
+		typeCost := m[name.Value]
+		c, err := costFromDirectives(directives)
+		if err != nil {
+			return nil, fmt.Errorf("type %s: %w", name.Value, err)
+		}
+		if c != nil {
+			typeCost.Cost = c
+		}
+		for _, f := range fields {
+			if f == nil || f.Name == nil {
+				continue
+			}
+			c, err := costFromDirectives(f.Directives)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %w", name.Value, f.Name.Value, err)
+			}
+			if c == nil {
+				continue
+			}
+			if typeCost.Fields == nil {
+				typeCost.Fields = FieldsCost{}
+			}
+			typeCost.Fields[f.Name.Value] = *c
+		}
+		if typeCost.Cost != nil || len(typeCost.Fields) > 0 {
+			m[name.Value] = typeCost
+		}
+	}
+	return m, nil
+}
+
+func costFromDirectives(directives []*ast.Directive) (*Cost, error) {
+	for _, d := range directives {
+		if d == nil || d.Name == nil || d.Name.Value != CostDirectiveName {
+			continue
+		}
+		return costFromDirective(d)
+	}
+	return nil, nil
+}
+
+func costFromDirective(d *ast.Directive) (*Cost, error) {
+	c := &Cost{UseMultipliers: true}
+	for _, arg := range d.Arguments {
+		if arg == nil || arg.Name == nil {
+			continue
+		}
+		switch arg.Name.Value {
+		case "complexity":
+			v, ok := arg.Value.(*ast.IntValue)
+			if !ok {
+				return nil, fmt.Errorf("@%s: complexity must be Int", CostDirectiveName)
+			}
+			n, err := strconv.Atoi(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("@%s: invalid complexity: %w", CostDirectiveName, err)
+			}
+			c.Complexity = n
+		case "multipliers":
+			names, err := stringsFromValue(arg.Value)
+			if err != nil {
+				return nil, fmt.Errorf("@%s: multipliers %w", CostDirectiveName, err)
+			}
+			c.Multipliers = names
+		case "useMultipliers":
+			v, ok := arg.Value.(*ast.BooleanValue)
+			if !ok {
+				return nil, fmt.Errorf("@%s: useMultipliers must be Boolean", CostDirectiveName)
+			}
+			c.UseMultipliers = v.Value
+		default:
+			return nil, fmt.Errorf("@%s: unknown argument %q", CostDirectiveName, arg.Name.Value)
+		}
+	}
+	return c, nil
+}
+
+func stringsFromValue(v ast.Value) ([]string, error) {
+	switch v := v.(type) {
+	case *ast.StringValue:
+		return []string{v.Value}, nil
+	case *ast.ListValue:
+		var names []string
+		for _, item := range v.Values {
+			s, ok := item.(*ast.StringValue)
+			if !ok {
+				return nil, errors.New("must be [String]")
+			}
+			names = append(names, s.Value)
+		}
+		return names, nil
+	default:
+		return nil, errors.New("must be [String]")
+	}
+}
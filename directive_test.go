@@ -0,0 +1,59 @@
+package gqlcost
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCostMapFromSDL(t *testing.T) {
+	m, err := CostMapFromSDL(`
+		type Query {
+			users(limit: Int): [User] @cost(complexity: 2, multipliers: ["limit"])
+			version: String @cost(complexity: 1, useMultipliers: false)
+			noCost: String
+		}
+		type User @cost(complexity: 3) {
+			name: String
+		}
+		extend type User {
+			friends(first: Int): [User] @cost(complexity: 1, multipliers: "first")
+		}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exp := CostMap{
+		"Query": {Fields: FieldsCost{
+			"users":   {UseMultipliers: true, Complexity: 2, Multipliers: []string{"limit"}},
+			"version": {Complexity: 1},
+		}},
+		"User": {
+			Cost: &Cost{UseMultipliers: true, Complexity: 3},
+			Fields: FieldsCost{
+				"friends": {UseMultipliers: true, Complexity: 1, Multipliers: []string{"first"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(m, exp) {
+		t.Fatalf("unexpected CostMap:\nwant=%+v\ngot=%+v", exp, m)
+	}
+}
+
+func TestCostMapFromSDL_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		sdl string
+		err string
+	}{
+		{`type Query { a: Int @cost(complexity: "1") }`, "field Query.a: @cost: complexity must be Int"},
+		{`type Query { a: Int @cost(multipliers: [1]) }`, "field Query.a: @cost: multipliers must be [String]"},
+		{`type Query @cost(foo: 1) { a: Int }`, `type Query: @cost: unknown argument "foo"`},
+	} {
+		_, err := CostMapFromSDL(tc.sdl)
+		if err == nil {
+			t.Errorf("error expected for %s", tc.sdl)
+			continue
+		}
+		if err.Error() != tc.err {
+			t.Errorf("unexpected error:\nwant=%s\ngot=%s", tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package gqlcost
+
+import "fmt"
+
+// Error codes which are put in "code" of extensions of errors.
+const (
+	// CodeCostLimitExceeded is a code for CostLimitError.
+	CodeCostLimitExceeded = "COST_LIMIT_EXCEEDED"
+
+	// CodeDepthLimitExceeded is a code for DepthLimitError.
+	CodeDepthLimitExceeded = "DEPTH_LIMIT_EXCEEDED"
+
+	// CodeFieldLimitExceeded is a code for FieldLimitError.
+	CodeFieldLimitExceeded = "FIELD_LIMIT_EXCEEDED"
+
+	// CodeAliasLimitExceeded is a code for AliasLimitError.
+	CodeAliasLimitExceeded = "ALIAS_LIMIT_EXCEEDED"
+
+	// CodeIntrospectionLimitExceeded is a code for IntrospectionLimitError.
+	CodeIntrospectionLimitExceeded = "INTROSPECTION_LIMIT_EXCEEDED"
+)
+
+// CostLimitError is an error when cost of an operation exceeds the maximum
+// cost. It implements gqlerrors.ExtendedError, so formatted errors have
+// extensions like this:
+//
+//	{
+//	  "code": "COST_LIMIT_EXCEEDED",
+//	  "maximumCost": 100,
+//	  "actualCost": 120,
+//	  "operationName": "GetUsers"
+//	}
+//
+// When AnalysisOptions.BreakdownInErrors is true, "breakdown" is added to the
+// extensions.
+type CostLimitError struct {
+	MaximumCost   int
+	ActualCost    int
+	OperationName string
+	Breakdown     []*CostBreakdown
+}
+
+func (e *CostLimitError) Error() string {
+	return fmt.Sprintf("The query exceeds the maximum cost of %d. Actual cost is %d", e.MaximumCost, e.ActualCost)
+}
+
+// Extensions returns extensions for GraphQL errors.
+func (e *CostLimitError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{
+		"code":          CodeCostLimitExceeded,
+		"maximumCost":   e.MaximumCost,
+		"actualCost":    e.ActualCost,
+		"operationName": e.OperationName,
+	}
+	if e.Breakdown != nil {
+		ext["breakdown"] = e.Breakdown
+	}
+	return ext
+}
+
+// DepthLimitError is an error when depth of an operation exceeds the maximum
+// depth. It implements gqlerrors.ExtendedError.
+type DepthLimitError struct {
+	MaximumDepth  int
+	ActualDepth   int
+	OperationName string
+}
+
+func (e *DepthLimitError) Error() string {
+	return fmt.Sprintf("The query exceeds the maximum depth of %d. Actual depth is %d", e.MaximumDepth, e.ActualDepth)
+}
+
+// Extensions returns extensions for GraphQL errors.
+func (e *DepthLimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":          CodeDepthLimitExceeded,
+		"maximumDepth":  e.MaximumDepth,
+		"actualDepth":   e.ActualDepth,
+		"operationName": e.OperationName,
+	}
+}
+
+// FieldLimitError is an error when number of fields in an operation exceeds
+// the maximum. It implements gqlerrors.ExtendedError.
+type FieldLimitError struct {
+	MaximumFields int
+
+	// ActualFields is number of fields which are counted until it exceeds
+	// MaximumFields, because rest of the operation is not analyzed.
+	ActualFields int
+
+	OperationName string
+}
+
+func (e *FieldLimitError) Error() string {
+	return fmt.Sprintf("The query exceeds the maximum number of fields of %d. Actual number is %d", e.MaximumFields, e.ActualFields)
+}
+
+// Extensions returns extensions for GraphQL errors.
+func (e *FieldLimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":          CodeFieldLimitExceeded,
+		"maximumFields": e.MaximumFields,
+		"actualFields":  e.ActualFields,
+		"operationName": e.OperationName,
+	}
+}
+
+// AliasLimitError is an error when number of aliases in a selection set
+// exceeds the maximum. It implements gqlerrors.ExtendedError.
+type AliasLimitError struct {
+	MaximumAliases int
+	ActualAliases  int
+
+	// SelectionSet is a name of the selection set: name or alias of the
+	// field, name of the fragment or the operation.
+	SelectionSet string
+}
+
+func (e *AliasLimitError) Error() string {
+	return fmt.Sprintf("The selection set %q exceeds the maximum number of aliases of %d. Actual number is %d", e.SelectionSet, e.MaximumAliases, e.ActualAliases)
+}
+
+// Extensions returns extensions for GraphQL errors.
+func (e *AliasLimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":           CodeAliasLimitExceeded,
+		"maximumAliases": e.MaximumAliases,
+		"actualAliases":  e.ActualAliases,
+		"selectionSet":   e.SelectionSet,
+	}
+}
+
+// IntrospectionLimitError is an error when cost of introspection fields in
+// an operation exceeds the maximum. It implements gqlerrors.ExtendedError.
+type IntrospectionLimitError struct {
+	MaximumCost   int
+	ActualCost    int
+	OperationName string
+}
+
+func (e *IntrospectionLimitError) Error() string {
+	return fmt.Sprintf("The introspection exceeds the maximum cost of %d. Actual cost is %d", e.MaximumCost, e.ActualCost)
+}
+
+// Extensions returns extensions for GraphQL errors.
+func (e *IntrospectionLimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":          CodeIntrospectionLimitExceeded,
+		"maximumCost":   e.MaximumCost,
+		"actualCost":    e.ActualCost,
+		"operationName": e.OperationName,
+	}
+}
@@ -0,0 +1,43 @@
+package gqlcost
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+func TestCostLimitError(t *testing.T) {
+	doc := parseQuery(t, `query Foo { customCost }`)
+	_, err := Analyze(schema, doc, AnalysisOptions{
+		MaximumCost: 1,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+		},
+	})
+	var fe gqlerrors.FormattedError
+	if !errors.As(err, &fe) {
+		t.Fatalf("error should be FormattedError: %T", err)
+	}
+	exp := map[string]interface{}{
+		"code":          "COST_LIMIT_EXCEEDED",
+		"maximumCost":   1,
+		"actualCost":    8,
+		"operationName": "Foo",
+	}
+	if !reflect.DeepEqual(fe.Extensions, exp) {
+		t.Fatalf("unexpected extensions:\nwant=%+v\ngot=%+v", exp, fe.Extensions)
+	}
+	ge, ok := fe.OriginalError().(*gqlerrors.Error)
+	if !ok {
+		t.Fatalf("original error should be *gqlerrors.Error: %T", fe.OriginalError())
+	}
+	cle, ok := ge.OriginalError.(*CostLimitError)
+	if !ok {
+		t.Fatalf("original error should be CostLimitError: %T", ge.OriginalError)
+	}
+	if cle.ActualCost != 8 {
+		t.Fatalf("wrong actual cost: want=%d got=%d", 8, cle.ActualCost)
+	}
+}
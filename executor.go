@@ -0,0 +1,55 @@
+package gqlcost
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Rules returns validation rules which consist of graphql.SpecifiedRules and
+// a cost analysis rule. It doesn't modify graphql.SpecifiedRules.
+func Rules(opts AnalysisOptions) []graphql.ValidationRuleFn {
+	rules := make([]graphql.ValidationRuleFn, 0, len(graphql.SpecifiedRules)+1)
+	rules = append(rules, graphql.SpecifiedRules...)
+	return append(rules, AnalysisRule(opts))
+}
+
+// ValidateDocument validates a document with graphql.SpecifiedRules and a
+// cost analysis rule for opts.
+func ValidateDocument(schema *graphql.Schema, doc *ast.Document, opts AnalysisOptions) graphql.ValidationResult {
+	return graphql.ValidateDocument(schema, doc, Rules(opts))
+}
+
+// Do executes a request as same as graphql.Do, but validates it with a cost
+// analysis rule for opts, instead of global graphql.SpecifiedRules.
+// p.VariableValues and p.OperationName override Valiables and OperationName
+// of opts.
+//
+// Extensions of the schema are notified only about execution, not about
+// parse and validation.
+func Do(p graphql.Params, opts AnalysisOptions) *graphql.Result {
+	src := source.NewSource(&source.Source{
+		Body: []byte(p.RequestString),
+		Name: "GraphQL request",
+	})
+	doc, err := parser.Parse(parser.ParseParams{Source: src})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	opts.Valiables = p.VariableValues
+	opts.OperationName = p.OperationName
+	vr := ValidateDocument(&p.Schema, doc, opts)
+	if !vr.IsValid {
+		return &graphql.Result{Errors: vr.Errors}
+	}
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        p.Schema,
+		Root:          p.RootObject,
+		AST:           doc,
+		OperationName: p.OperationName,
+		Args:          p.VariableValues,
+		Context:       p.Context,
+	})
+}
@@ -0,0 +1,77 @@
+package gqlcost
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestRules(t *testing.T) {
+	n := len(graphql.SpecifiedRules)
+	rules := Rules(AnalysisOptions{})
+	if len(rules) != n+1 {
+		t.Fatalf("unexpected number of rules: want=%d got=%d", n+1, len(rules))
+	}
+	if len(graphql.SpecifiedRules) != n {
+		t.Fatal("graphql.SpecifiedRules is modified")
+	}
+}
+
+func TestValidateDocument(t *testing.T) {
+	opts := AnalysisOptions{
+		MaximumCost: 5,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+		},
+	}
+	vr := ValidateDocument(schema, parseQuery(t, `query { customCost }`), opts)
+	if vr.IsValid || len(vr.Errors) != 1 {
+		t.Fatalf("one error expected: %+v", vr)
+	}
+	vr = ValidateDocument(schema, parseQuery(t, `query { defaultCost }`), opts)
+	if !vr.IsValid {
+		t.Fatalf("unexpected errors: %+v", vr.Errors)
+	}
+}
+
+func TestDo(t *testing.T) {
+	opts := AnalysisOptions{
+		MaximumCost: 30,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{
+				"customCostWithResolver": limitCost(4),
+			}},
+		},
+	}
+	query := `
+		query A($limit: Int) { customCostWithResolver(limit: $limit) }
+		query B { customCostWithResolver(limit: 100) }`
+	r := Do(graphql.Params{
+		Schema:         *schema,
+		RequestString:  query,
+		OperationName:  "A",
+		VariableValues: map[string]interface{}{"limit": 5},
+	}, opts)
+	if r.HasErrors() {
+		t.Fatalf("unexpected errors: %+v", r.Errors)
+	}
+	data, _ := r.Data.(map[string]interface{})
+	if data["customCostWithResolver"] != 5 {
+		t.Fatalf("unexpected data: %+v", r.Data)
+	}
+
+	r = Do(graphql.Params{
+		Schema:         *schema,
+		RequestString:  query,
+		OperationName:  "A",
+		VariableValues: map[string]interface{}{"limit": 10},
+	}, opts)
+	if len(r.Errors) != 1 || r.Errors[0].Extensions["code"] != CodeCostLimitExceeded {
+		t.Fatalf("cost limit error expected: %+v", r.Errors)
+	}
+
+	r = Do(graphql.Params{Schema: *schema, RequestString: `query {`}, opts)
+	if !r.HasErrors() {
+		t.Fatal("syntax error expected")
+	}
+}
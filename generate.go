@@ -0,0 +1,212 @@
+package gqlcost
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// GenerateOptions provides options for GenerateCostMap.
+type GenerateOptions struct {
+	// Multipliers enumerates names of arguments of list fields to be used
+	// as multipliers. Default is "first", "last", "limit" and "pageSize".
+	Multipliers []string
+
+	// ResolverComplexity is complexity of fields which have resolvers or
+	// return object, interface or union types. Default is 1.
+	ResolverComplexity int
+
+	// MutationComplexity is base complexity of fields of the mutation type.
+	// Default is 10.
+	MutationComplexity int
+}
+
+var defaultGenerateMultipliers = []string{"first", "last", "limit", "pageSize"}
+
+func (o GenerateOptions) multipliers() []string {
+	if len(o.Multipliers) == 0 {
+		return defaultGenerateMultipliers
+	}
+	return o.Multipliers
+}
+
+func (o GenerateOptions) resolverComplexity() int {
+	if o.ResolverComplexity == 0 {
+		return 1
+	}
+	return o.ResolverComplexity
+}
+
+func (o GenerateOptions) mutationComplexity() int {
+	if o.MutationComplexity == 0 {
+		return 10
+	}
+	return o.MutationComplexity
+}
+
+// GenerateCostMap generates a starting CostMap from the schema by
+// heuristics, to be tuned by hand:
+//
+//   - List fields which have arguments in GenerateOptions.Multipliers use
+//     them as multipliers, with StrategyMax when two or more are present.
+//   - Fields of object types which have resolvers or return object,
+//     interface or union types have complexity
+//     GenerateOptions.ResolverComplexity (1), and others (ex. scalars
+//     which are resolved by the default resolver) have 0. The latter
+//     works for schemas without resolvers, like ones built from SDL.
+//   - Fields of the mutation type have GenerateOptions.MutationComplexity
+//     (10).
+//
+// Fields with zero complexity and no multipliers are omitted, and others
+// have UseMultipliers to be charged for each item of parent lists. The
+// CostMap has names of types as they are, so call CostMap.AddWrappedTypes
+// before using it for analysis.
+func GenerateCostMap(schema *graphql.Schema, opts GenerateOptions) CostMap {
+	m := CostMap{}
+	var mutationName string
+	if mt := schema.MutationType(); mt != nil {
+		mutationName = mt.Name()
+	}
+	for name, t := range schema.TypeMap() {
+		if isIntrospectionField(name) {
+			continue
+		}
+		var fm graphql.FieldDefinitionMap
+		switch t := t.(type) {
+		case *graphql.Object:
+			fm = t.Fields()
+		case *graphql.Interface:
+			fm = t.Fields()
+		default:
+			continue
+		}
+		fields := FieldsCost{}
+		for fieldName, f := range fm {
+			var c Cost
+			switch {
+			case name == mutationName:
+				c.Complexity = opts.mutationComplexity()
+			case f.Resolve != nil, graphql.IsCompositeType(graphql.GetNamed(f.Type)):
+				c.Complexity = opts.resolverComplexity()
+			}
+			if isListType(f.Type) {
+				c.Multipliers = generateMultipliers(f, opts.multipliers())
+			}
+			if len(c.Multipliers) > 0 {
+				if c.Complexity == 0 {
+					c.Complexity = 1
+				}
+				if len(c.Multipliers) > 1 {
+					c.Strategy = StrategyMax
+				}
+			}
+			if c.Complexity == 0 {
+				continue
+			}
+			// fields are charged for each item of parent lists, as same as
+			// the default of @cost directives.
+			c.UseMultipliers = true
+			fields[fieldName] = c
+		}
+		if len(fields) > 0 {
+			m[name] = TypeCost{Fields: fields}
+		}
+	}
+	return m
+}
+
+// generateMultipliers returns names of arguments of f which are in names.
+func generateMultipliers(f *graphql.FieldDefinition, names []string) []string {
+	var multipliers []string
+	for _, n := range names {
+		for _, a := range f.Args {
+			if a.PrivateName == n {
+				multipliers = append(multipliers, n)
+				break
+			}
+		}
+	}
+	return multipliers
+}
+
+// WriteGo writes the CostMap as Go source, which declares a variable named
+// varName in package pkg.
+func (m CostMap) WriteGo(w io.Writer, pkg, varName string) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintf(&b, "import \"github.com/koron-go/gqlcost\"\n\n")
+	fmt.Fprintf(&b, "var %s = gqlcost.CostMap{\n", varName)
+	for _, typName := range sortedKeys(m) {
+		tc := m[typName]
+		fmt.Fprintf(&b, "%q: {\n", typName)
+		if tc.Cost != nil {
+			fmt.Fprintf(&b, "Cost: &gqlcost.Cost%s,\n", goCost(*tc.Cost))
+		}
+		if len(tc.Fields) > 0 {
+			fmt.Fprintf(&b, "Fields: gqlcost.FieldsCost{\n")
+			for _, fieldName := range sortedKeys(tc.Fields) {
+				fmt.Fprintf(&b, "%q: %s,\n", fieldName, goCost(tc.Fields[fieldName]))
+			}
+			fmt.Fprintf(&b, "},\n")
+		}
+		fmt.Fprintf(&b, "},\n")
+	}
+	fmt.Fprintf(&b, "}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+var goStrategies = map[MultiplierStrategy]string{
+	StrategySum:          "gqlcost.StrategySum",
+	StrategyMax:          "gqlcost.StrategyMax",
+	StrategyProduct:      "gqlcost.StrategyProduct",
+	StrategyFirstPresent: "gqlcost.StrategyFirstPresent",
+}
+
+// goCost returns a composite literal of Cost without its type.
+func goCost(c Cost) string {
+	var elems []string
+	if c.UseMultipliers {
+		elems = append(elems, "UseMultipliers: true")
+	}
+	if c.Complexity != 0 {
+		elems = append(elems, "Complexity: "+strconv.Itoa(c.Complexity))
+	}
+	if len(c.Multipliers) > 0 {
+		quoted := make([]string, len(c.Multipliers))
+		for i, n := range c.Multipliers {
+			quoted[i] = strconv.Quote(n)
+		}
+		elems = append(elems, "Multipliers: []string{"+strings.Join(quoted, ", ")+"}")
+	}
+	if c.Strategy != "" {
+		s, ok := goStrategies[c.Strategy]
+		if !ok {
+			s = "gqlcost.MultiplierStrategy(" + strconv.Quote(string(c.Strategy)) + ")"
+		}
+		elems = append(elems, "Strategy: "+s)
+	}
+	if c.AssumedSize != 0 {
+		elems = append(elems, "AssumedSize: "+strconv.Itoa(c.AssumedSize))
+	}
+	return "{" + strings.Join(elems, ", ") + "}"
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package gqlcost
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func newGenerateSchema(t *testing.T) *graphql.Schema {
+	t.Helper()
+	resolve := func(p graphql.ResolveParams) (interface{}, error) {
+		return nil, nil
+	}
+	intArg := &graphql.ArgumentConfig{Type: graphql.Int}
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.Fields{
+			"title": &graphql.Field{Type: graphql.String},
+			"likes": &graphql.Field{Type: graphql.Int, Resolve: resolve},
+		},
+	})
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+			"posts": &graphql.Field{
+				Type: graphql.NewList(postType),
+				Args: graphql.FieldConfigArgument{"first": intArg, "last": intArg},
+			},
+			"tags": &graphql.Field{
+				Type: graphql.NewList(graphql.String),
+				Args: graphql.FieldConfigArgument{"offset": intArg},
+			},
+		},
+	})
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"users": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(userType)),
+				Args:    graphql.FieldConfigArgument{"pageSize": intArg},
+				Resolve: resolve,
+			},
+			"user": &graphql.Field{
+				Type:    userType,
+				Args:    graphql.FieldConfigArgument{"limit": intArg},
+				Resolve: resolve,
+			},
+		},
+	})
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"addPost": &graphql.Field{Type: postType, Resolve: resolve},
+		},
+	})
+	s, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+	if err != nil {
+		t.Fatalf("failed to create schema: %s", err)
+	}
+	return &s
+}
+
+func TestGenerateCostMap(t *testing.T) {
+	s := newGenerateSchema(t)
+	m := GenerateCostMap(s, GenerateOptions{})
+	var b bytes.Buffer
+	if err := m.WriteGo(&b, "example", "costMap"); err != nil {
+		t.Fatal(err)
+	}
+	exp := `package example
+
+import "github.com/koron-go/gqlcost"
+
+var costMap = gqlcost.CostMap{
+	"Mutation": {
+		Fields: gqlcost.FieldsCost{
+			"addPost": {UseMultipliers: true, Complexity: 10},
+		},
+	},
+	"Post": {
+		Fields: gqlcost.FieldsCost{
+			"likes": {UseMultipliers: true, Complexity: 1},
+		},
+	},
+	"Query": {
+		Fields: gqlcost.FieldsCost{
+			"user":  {UseMultipliers: true, Complexity: 1},
+			"users": {UseMultipliers: true, Complexity: 1, Multipliers: []string{"pageSize"}},
+		},
+	},
+	"User": {
+		Fields: gqlcost.FieldsCost{
+			"posts": {UseMultipliers: true, Complexity: 1, Multipliers: []string{"first", "last"}, Strategy: gqlcost.StrategyMax},
+		},
+	},
+}
+`
+	if got := b.String(); got != exp {
+		t.Fatalf("unexpected source:\nwant=%s\ngot=%s", exp, got)
+	}
+	if errs := m.Validate(s); len(errs) > 0 {
+		t.Fatalf("generated cost map is invalid: %v", errs)
+	}
+
+	// users: 10, posts: 2 * 10, likes: 20 items.
+	m.AddWrappedTypes(s)
+	r, err := Analyze(s, parseQuery(t, `{ users(pageSize: 10) { posts(first: 2) { likes } } }`), AnalysisOptions{CostMap: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Cost != 50 {
+		t.Fatalf("unexpected cost: %d", r.Cost)
+	}
+}
+
+func TestGenerateCostMap_Options(t *testing.T) {
+	m := GenerateCostMap(newGenerateSchema(t), GenerateOptions{
+		Multipliers:        []string{"offset"},
+		ResolverComplexity: 2,
+		MutationComplexity: 5,
+	})
+	if c := m["Mutation"].Fields["addPost"]; c.Complexity != 5 {
+		t.Errorf("unexpected cost of addPost: %+v", c)
+	}
+	if c := m["Query"].Fields["users"]; c.Complexity != 2 || !c.UseMultipliers {
+		t.Errorf("unexpected cost of users: %+v", c)
+	}
+	if c := m["User"].Fields["tags"]; c.Complexity != 1 || len(c.Multipliers) != 1 || c.Multipliers[0] != "offset" {
+		t.Errorf("unexpected cost of tags: %+v", c)
+	}
+	if c := m["User"].Fields["posts"]; c.Complexity != 2 || !c.UseMultipliers {
+		t.Errorf("unexpected cost of posts: %+v", c)
+	}
+	if _, ok := m["User"].Fields["name"]; ok {
+		t.Errorf("name should be omitted: %+v", m["User"])
+	}
+}
@@ -2,4 +2,7 @@ module github.com/koron-go/gqlcost
 
 go 1.25
 
-require github.com/graphql-go/graphql v0.8.1
+require (
+	github.com/graphql-go/graphql v0.8.1
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -10,10 +10,62 @@ import (
 type AnalysisOptions struct {
 	MaximumCost int
 	DefaultCost int
-	Valiables   map[string]interface{}
+
+	// Valiables provides values of variables which are referred from
+	// arguments to calculate multipliers.  It is fixed when a rule is
+	// created, use NewRule to give variables for each request.
+	Valiables map[string]interface{}
+
+	// OperationName is name of the operation to be evaluated. When it is
+	// empty, all operations in the document are evaluated. MaximumCost is
+	// applied to each operation separately.
+	OperationName string
+
+	// Breakdown enables to collect cost breakdown for each field into
+	// OperationCost. A fragment which is spread again at the same path has
+	// no children in the breakdown.
+	//
+	// Breakdowns are expanded for each path, so it takes time for fragments
+	// which are spread under many fields. Use it with MaximumFields for
+	// untrusted queries.
+	Breakdown bool
+
+	// BreakdownInErrors enables to attach cost breakdown to extensions of
+	// errors for exceeding MaximumCost.
+	BreakdownInErrors bool
 
 	CostMap         CostMap
 	ComplexityRange ComplexityRange
+
+	// Connection provides options for Relay style connections.
+	Connection ConnectionOptions
+
+	// DefaultListSize is an assumed size of lists which is used as a
+	// multiplier, when a field returns a list and no multipliers are
+	// available. With empty CostMap, fields in the list cost DefaultCost
+	// for each item. It takes effect also for connections with Connection.
+	// Cost.AssumedSize overrides it for each field.
+	DefaultListSize int
+
+	// MaximumFields is the maximum number of fields in each operation,
+	// which is counted after expanding fragments. Analysis of the operation
+	// stops when it is exceeded. No limits when it is zero.
+	MaximumFields int
+
+	// MaximumAliases is the maximum number of aliases in each selection
+	// set, which are counted after merging fragments. No limits when it is
+	// zero.
+	MaximumAliases int
+
+	// Introspection provides a policy for introspection fields.
+	Introspection IntrospectionOptions
+
+	// Mutation provides a cost policy for mutations.
+	Mutation OperationOptions
+
+	// Subscription provides a cost policy for subscriptions. Cost of a
+	// subscription is a cost of each event.
+	Subscription OperationOptions
 }
 
 var addRule sync.Once
@@ -35,6 +87,13 @@ func AnalysisRule(opts AnalysisOptions) graphql.ValidationRuleFn {
 	return r.validationRule
 }
 
+// NewRule provides cost analysis rule (function) with variables for a
+// request. variables override AnalysisOptions.Valiables.
+func NewRule(opts AnalysisOptions, variables map[string]interface{}) graphql.ValidationRuleFn {
+	opts.Valiables = variables
+	return AnalysisRule(opts)
+}
+
 type costAnalysisRule struct {
 	opts AnalysisOptions
 }
@@ -22,3 +22,28 @@ func TestAnalysisRule(t *testing.T) {
 		t.Fatal("VisitorOpts is nil")
 	}
 }
+
+func TestNewRule(t *testing.T) {
+	opts := AnalysisOptions{
+		MaximumCost: 30,
+		CostMap: CostMap{
+			"Query": {Fields: FieldsCost{
+				"customCostWithResolver": limitCost(4),
+			}},
+		},
+	}
+	astDoc := parseQuery(t, `query($limit: Int) { customCostWithResolver(limit: $limit) }`)
+	for _, tc := range []struct {
+		limit float64
+		valid bool
+	}{
+		{5, true},
+		{10, false},
+	} {
+		rule := NewRule(opts, map[string]interface{}{"limit": tc.limit})
+		vr := graphql.ValidateDocument(schema, astDoc, []graphql.ValidationRuleFn{rule})
+		if vr.IsValid != tc.valid {
+			t.Errorf("unexpected validation result for limit=%v: want=%t got=%t %+v", tc.limit, tc.valid, vr.IsValid, vr.Errors)
+		}
+	}
+}
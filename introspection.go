@@ -0,0 +1,52 @@
+package gqlcost
+
+import "strings"
+
+// IntrospectionPolicy is a policy to cost introspection fields: "__schema",
+// "__type" and "__typename".
+type IntrospectionPolicy int
+
+const (
+	// IntrospectionDefault costs introspection fields as DefaultCost, and
+	// doesn't traverse their selections. This is default.
+	IntrospectionDefault IntrospectionPolicy = iota
+
+	// IntrospectionExclude excludes introspection fields from cost.
+	IntrospectionExclude
+
+	// IntrospectionFixed charges IntrospectionOptions.Cost for each
+	// "__schema" and "__type" field. "__typename" is free.
+	IntrospectionFixed
+)
+
+// IntrospectionOptions provides options for introspection fields.
+type IntrospectionOptions struct {
+	// Policy is a policy to cost introspection fields.
+	Policy IntrospectionPolicy
+
+	// Cost is a fixed cost for IntrospectionFixed.
+	Cost int
+
+	// MaximumCost is the maximum of total cost of introspection fields in
+	// each operation, which are costed by Policy. Introspection beyond it
+	// is forbidden. No limits when it is zero.
+	MaximumCost int
+}
+
+func (io IntrospectionOptions) cost(name string, defaultCost int) int {
+	switch io.Policy {
+	case IntrospectionExclude:
+		return 0
+	case IntrospectionFixed:
+		if name == "__typename" {
+			return 0
+		}
+		return io.Cost
+	default:
+		return defaultCost
+	}
+}
+
+func isIntrospectionField(name string) bool {
+	return strings.HasPrefix(name, "__")
+}
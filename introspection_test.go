@@ -0,0 +1,46 @@
+package gqlcost
+
+import "testing"
+
+func TestIntrospection(t *testing.T) {
+	query := `
+		query {
+			__schema { types { name } }
+			__type(name: "Query") { name }
+			__typename
+			customCost
+		}`
+	costMap := CostMap{
+		"Query": {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+	}
+	for _, tc := range []struct {
+		name string
+		io   IntrospectionOptions
+		cost int
+	}{
+		{"default", IntrospectionOptions{}, 11},
+		{"exclude", IntrospectionOptions{Policy: IntrospectionExclude}, 8},
+		{"fixed", IntrospectionOptions{Policy: IntrospectionFixed, Cost: 50}, 108},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testCost(t, query, AnalysisOptions{
+				DefaultCost:   1,
+				CostMap:       costMap,
+				Introspection: tc.io,
+			}, tc.cost)
+		})
+	}
+}
+
+func TestIntrospection_MaximumCost(t *testing.T) {
+	opts := AnalysisOptions{
+		Introspection: IntrospectionOptions{
+			Policy:      IntrospectionFixed,
+			Cost:        100,
+			MaximumCost: 100,
+		},
+	}
+	testCost(t, `query { __schema { types { name } } __typename }`, opts, 100)
+	testErrs(t, `query { __schema { types { name } } __type(name: "Query") { name } }`, opts,
+		"The introspection exceeds the maximum cost of 100. Actual cost is 200")
+}
@@ -0,0 +1,73 @@
+package gqlcost
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadCostMap loads CostMap from JSON or YAML. It is treated as JSON when
+// it starts with "{", otherwise as YAML. Unknown keys are errors.
+//
+// The schema is an object which maps names of types to TypeCost:
+//
+//	Query:
+//	  fields:
+//	    users:
+//	      complexity: 2
+//	      useMultipliers: true
+//	      multipliers: [first, last]
+//	      strategy: max
+//	User:
+//	  cost:
+//	    complexity: 1
+//
+// Keys of Cost are "complexity", "useMultipliers", "multipliers",
+// "strategy" and "assumedSize". "strategy" accepts names of
+// MultiplierStrategy: "sum" (default), "max", "product", "firstPresent" and
+// names which are registered by RegisterStrategy. MultiplierFunc can't be
+// loaded.
+func LoadCostMap(r io.Reader) (CostMap, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var m CostMap
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		if err := d.Decode(&m); err != nil {
+			return nil, fmt.Errorf("gqlcost: failed to decode JSON: %w", err)
+		}
+	} else {
+		d := yaml.NewDecoder(bytes.NewReader(b))
+		d.KnownFields(true)
+		if err := d.Decode(&m); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("gqlcost: failed to decode YAML: %w", err)
+		}
+	}
+	if m == nil {
+		m = CostMap{}
+	}
+	if err := m.checkStrategies(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m CostMap) checkStrategies() error {
+	for typName, tc := range m {
+		if tc.Cost != nil && !tc.Cost.Strategy.valid() {
+			return fmt.Errorf("gqlcost: unknown strategy %q for type %s", tc.Cost.Strategy, typName)
+		}
+		for fieldName, c := range tc.Fields {
+			if !c.Strategy.valid() {
+				return fmt.Errorf("gqlcost: unknown strategy %q for field %s.%s", c.Strategy, typName, fieldName)
+			}
+		}
+	}
+	return nil
+}
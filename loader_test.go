@@ -0,0 +1,101 @@
+package gqlcost
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadCostMap(t *testing.T) {
+	exp := CostMap{
+		"Query": {Fields: FieldsCost{
+			"users": {
+				UseMultipliers: true,
+				Complexity:     2,
+				Multipliers:    []string{"first", "last"},
+				Strategy:       StrategyMax,
+			},
+		}},
+		"User": {Cost: &Cost{Complexity: 1}},
+	}
+	for _, tc := range []struct {
+		name string
+		src  string
+	}{
+		{"json", `{
+			"Query": {
+				"fields": {
+					"users": {
+						"complexity": 2,
+						"useMultipliers": true,
+						"multipliers": ["first", "last"],
+						"strategy": "max"
+					}
+				}
+			},
+			"User": {"cost": {"complexity": 1}}
+		}`},
+		{"yaml", `
+Query:
+  fields:
+    users:
+      complexity: 2
+      useMultipliers: true
+      multipliers: [first, last]
+      strategy: max
+User:
+  cost:
+    complexity: 1
+`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := LoadCostMap(strings.NewReader(tc.src))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(m, exp) {
+				t.Fatalf("unexpected CostMap:\nwant=%+v\ngot=%+v", exp, m)
+			}
+		})
+	}
+}
+
+func TestLoadCostMap_Empty(t *testing.T) {
+	m, err := LoadCostMap(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if m == nil || len(m) != 0 {
+		t.Fatalf("empty CostMap expected: %+v", m)
+	}
+}
+
+func TestLoadCostMap_Invalid(t *testing.T) {
+	for _, src := range []string{
+		`{"Query": {"fieldz": {}}}`,
+		`{"Query": {"fields": {"a": {"complexity": "1"}}}}`,
+		"Query:\n  cost:\n    complexity: 1\n    unknown: 2\n",
+		"Query:\n  fields:\n    a:\n      strategy: foo\n",
+		"User:\n  cost:\n    strategy: bar\n",
+	} {
+		if _, err := LoadCostMap(strings.NewReader(src)); err == nil {
+			t.Errorf("error expected for %q", src)
+		}
+	}
+}
+
+func TestLoadCostMap_CustomStrategy(t *testing.T) {
+	src := "Query:\n  fields:\n    a:\n      strategy: twice\n"
+	if _, err := LoadCostMap(strings.NewReader(src)); err == nil {
+		t.Fatal("error expected for unregistered strategy")
+	}
+	RegisterStrategy("twice", func(values []int) int { return values[0] * 2 })
+	t.Cleanup(func() { unregisterStrategy("twice") })
+	m, err := LoadCostMap(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if m["Query"].Fields["a"].Strategy != "twice" {
+		t.Fatalf("unexpected CostMap: %+v", m)
+	}
+}
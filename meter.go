@@ -0,0 +1,283 @@
+package gqlcost
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Measurement provides estimated and actual cost of an executed operation.
+type Measurement struct {
+	// Name is name of the operation. It is empty for anonymous operation.
+	Name string `json:"name,omitempty"`
+
+	// Operation is type of the operation: "query", "mutation" or
+	// "subscription".
+	Operation string `json:"operation"`
+
+	// EstimatedCost is cost which is computed from the document before
+	// execution.
+	EstimatedCost int `json:"estimatedCost"`
+
+	// ActualCost is cost which is computed from resolved fields.
+	ActualCost int `json:"actualCost"`
+
+	// Resolvers is number of resolver invocations.
+	Resolvers int `json:"resolvers"`
+
+	// Fields enumerates measurements for each field, sorted by path.
+	Fields []FieldMeasurement `json:"fields,omitempty"`
+}
+
+// FieldMeasurement provides actual cost of a field in an operation.
+type FieldMeasurement struct {
+	// Path is a path to the field from the operation, separated by ".".
+	// Indexes of lists are not included.
+	Path string `json:"path"`
+
+	// Calls is number of resolver invocations for the field.
+	Calls int `json:"calls"`
+
+	// Items is total length of lists which are returned by the resolver.
+	Items int `json:"items,omitempty"`
+
+	// Cost is actual cost of the field, without children.
+	Cost int `json:"cost"`
+}
+
+// Meter is a graphql.Extension which measures actual cost of operations
+// during execution, to calibrate CostMap with real traffic.
+//
+// Actual cost is computed with the same CostMap as estimation. A field which
+// uses multipliers costs its complexity for each resolver invocation, which
+// is multiplied by length of a list when the resolver returns it. Other
+// fields cost their complexity once for each path.
+//
+//	meter := &gqlcost.Meter{
+//	    Options: opts,
+//	    Report: func(ctx context.Context, m *gqlcost.Measurement) {
+//	        log.Printf("%s: estimated=%d actual=%d", m.Name, m.EstimatedCost, m.ActualCost)
+//	    },
+//	}
+//	schema.AddExtensions(meter)
+type Meter struct {
+	// Options provides options to compute estimated and actual cost.
+	// Valiables and OperationName are given by the executed request.
+	Options AnalysisOptions
+
+	// Report is called with a measurement when execution of an operation
+	// is finished. It can be nil.
+	Report func(context.Context, *Measurement)
+
+	// InResult adds measurements to extensions of results as "cost".
+	InResult bool
+}
+
+var _ graphql.Extension = (*Meter)(nil)
+
+type meterKey struct{}
+
+type meterState struct {
+	mu        sync.Mutex
+	schema    *graphql.Schema
+	operation *ast.OperationDefinition
+	fragments map[string]ast.Definition
+	variables map[string]interface{}
+	fields    map[string]*FieldMeasurement
+	types     map[string]string
+	m         *Measurement
+}
+
+// Init implements graphql.Extension.
+func (mt *Meter) Init(ctx context.Context, p *graphql.Params) context.Context {
+	return ctx
+}
+
+// Name implements graphql.Extension.
+func (mt *Meter) Name() string {
+	return "cost"
+}
+
+// ParseDidStart implements graphql.Extension.
+func (mt *Meter) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	return ctx, func(error) {}
+}
+
+// ValidationDidStart implements graphql.Extension.
+func (mt *Meter) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	return ctx, func([]gqlerrors.FormattedError) {}
+}
+
+// ExecutionDidStart implements graphql.Extension.
+func (mt *Meter) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	st := &meterState{
+		fields: map[string]*FieldMeasurement{},
+		types:  map[string]string{},
+	}
+	ctx = context.WithValue(ctx, meterKey{}, st)
+	return ctx, func(*graphql.Result) {
+		m := mt.finish(st)
+		if m != nil && mt.Report != nil {
+			mt.Report(ctx, m)
+		}
+	}
+}
+
+// ResolveFieldDidStart implements graphql.Extension.
+func (mt *Meter) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	st, ok := ctx.Value(meterKey{}).(*meterState)
+	if !ok || info == nil {
+		return ctx, func(interface{}, error) {}
+	}
+	return ctx, func(v interface{}, err error) {
+		mt.resolved(st, info, v)
+	}
+}
+
+// HasResult implements graphql.Extension.
+func (mt *Meter) HasResult() bool {
+	return mt.InResult
+}
+
+// GetResult implements graphql.Extension.
+func (mt *Meter) GetResult(ctx context.Context) interface{} {
+	st, ok := ctx.Value(meterKey{}).(*meterState)
+	if !ok {
+		return nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.m
+}
+
+func (mt *Meter) resolved(st *meterState, info *graphql.ResolveInfo, v interface{}) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.operation == nil {
+		st.schema = &info.Schema
+		st.operation, _ = info.Operation.(*ast.OperationDefinition)
+		st.fragments = info.Fragments
+		st.variables = info.VariableValues
+	}
+	parentName := typName(info.ParentType)
+	if isIntrospectionField(parentName) {
+		// descendants of introspection fields are costed by the policy.
+		return
+	}
+	path := responsePath(info.Path)
+	st.types[path] = typName(info.ReturnType)
+	// CostMap is looked up by the type of the parent field as same as
+	// estimation, ex. "[User]" for fields of users in a list.
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		if t, ok := st.types[path[:i]]; ok && strings.Trim(t, "[]!") == parentName {
+			parentName = t
+		}
+	}
+	fm, ok := st.fields[path]
+	if !ok {
+		fm = &FieldMeasurement{Path: path}
+		st.fields[path] = fm
+	}
+	fm.Calls++
+	items := listLength(info.ReturnType, v)
+	fm.Items += items
+
+	if isIntrospectionField(info.FieldName) {
+		if fm.Calls == 1 {
+			fm.Cost = mt.Options.Introspection.cost(info.FieldName, mt.Options.DefaultCost)
+		}
+		return
+	}
+	isEdges := mt.Options.Connection.Enabled && isConnectionEdges(info.FieldName) && isConnectionType(info.ParentType)
+	if len(mt.Options.CostMap) == 0 && !isEdges {
+		if fm.Calls == 1 {
+			fm.Cost = mt.Options.DefaultCost
+		}
+		return
+	}
+	var node *ast.Field
+	if len(info.FieldASTs) > 0 {
+		node = info.FieldASTs[0]
+	}
+	cost := mt.Options.CostMap.getCost(parentName, node, typName(info.ReturnType))
+	if cost == nil && isEdges {
+		cost = &Cost{UseMultipliers: true, Complexity: mt.Options.Connection.complexity()}
+	}
+	switch {
+	case cost == nil:
+	case cost.UseMultipliers:
+		fm.Cost += cost.Complexity * max(items, 1)
+	case fm.Calls == 1:
+		fm.Cost = cost.Complexity
+	}
+}
+
+func (mt *Meter) finish(st *meterState) *Measurement {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.operation == nil {
+		return nil
+	}
+	m := &Measurement{
+		Name:      operationName(st.operation),
+		Operation: st.operation.GetOperation(),
+	}
+	m.ActualCost = mt.Options.operationOptions(m.Operation).BaseCost
+	for _, fm := range st.fields {
+		m.ActualCost += fm.Cost
+		m.Resolvers += fm.Calls
+		m.Fields = append(m.Fields, *fm)
+	}
+	sort.Slice(m.Fields, func(i, j int) bool {
+		return m.Fields[i].Path < m.Fields[j].Path
+	})
+
+	defs := []ast.Node{st.operation}
+	for _, fr := range st.fragments {
+		defs = append(defs, fr)
+	}
+	doc := ast.NewDocument(&ast.Document{Definitions: defs})
+	opts := mt.Options
+	opts.Valiables = st.variables
+	opts.OperationName = ""
+	opts.Breakdown = false
+	opts.BreakdownInErrors = false
+	r, _ := Analyze(st.schema, doc, opts)
+	m.EstimatedCost = r.Cost
+
+	st.m = m
+	return m
+}
+
+// responsePath returns a path of the response without indexes of lists.
+func responsePath(p *graphql.ResponsePath) string {
+	var keys []string
+	for _, k := range p.AsArray() {
+		if s, ok := k.(string); ok {
+			keys = append(keys, s)
+		}
+	}
+	return strings.Join(keys, ".")
+}
+
+// listLength returns length of v when t is a list type.
+func listLength(t graphql.Type, v interface{}) int {
+	if !isListType(t) || v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv.Len()
+	}
+	return 0
+}
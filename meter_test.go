@@ -0,0 +1,128 @@
+package gqlcost
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func newMeterSchema(t *testing.T, meter *Meter) graphql.Schema {
+	t.Helper()
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.Fields{
+			"title": &graphql.Field{Type: graphql.String},
+		},
+	})
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+			"posts": &graphql.Field{
+				Type: graphql.NewList(postType),
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return []map[string]interface{}{{"title": "a"}, {"title": "b"}}, nil
+				},
+			},
+		},
+	})
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"users": &graphql.Field{
+				Type: graphql.NewList(userType),
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return []map[string]interface{}{{"name": "x"}, {"name": "y"}, {"name": "z"}}, nil
+				},
+			},
+		},
+	})
+	s, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:      queryType,
+		Extensions: []graphql.Extension{meter},
+	})
+	if err != nil {
+		t.Fatalf("failed to create schema: %s", err)
+	}
+	return s
+}
+
+var meterOptions = AnalysisOptions{
+	CostMap: CostMap{
+		"Query":  {Fields: FieldsCost{"users": limitCost(1)}},
+		"[User]": {Fields: FieldsCost{"posts": limitCost(2)}},
+		"[Post]": {Fields: FieldsCost{"title": {Complexity: 1}}},
+	},
+}
+
+func TestMeter(t *testing.T) {
+	var reported *Measurement
+	meter := &Meter{
+		Options: meterOptions,
+		Report: func(ctx context.Context, m *Measurement) {
+			reported = m
+		},
+		InResult: true,
+	}
+	r := graphql.Do(graphql.Params{
+		Schema:         newMeterSchema(t, meter),
+		RequestString:  `query Q($n: Int) { users(limit: $n) { name posts(limit: 5) { title } } }`,
+		VariableValues: map[string]interface{}{"n": 10},
+	})
+	if len(r.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", r.Errors)
+	}
+	exp := &Measurement{
+		Name:          "Q",
+		Operation:     "query",
+		EstimatedCost: 111,
+		ActualCost:    16,
+		Resolvers:     13,
+		Fields: []FieldMeasurement{
+			{Path: "users", Calls: 1, Items: 3, Cost: 3},
+			{Path: "users.name", Calls: 3},
+			{Path: "users.posts", Calls: 3, Items: 6, Cost: 12},
+			{Path: "users.posts.title", Calls: 6, Cost: 1},
+		},
+	}
+	if !reflect.DeepEqual(reported, exp) {
+		t.Fatalf("unexpected measurement:\nwant=%+v\ngot=%+v", exp, reported)
+	}
+	if got := r.Extensions["cost"]; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected extension: %+v", got)
+	}
+}
+
+func TestMeter_Do(t *testing.T) {
+	var reported *Measurement
+	meter := &Meter{
+		Options: meterOptions,
+		Report: func(ctx context.Context, m *Measurement) {
+			reported = m
+		},
+	}
+	r := Do(graphql.Params{
+		Schema:        newMeterSchema(t, meter),
+		RequestString: `{ users(limit: 2) { ...f } } fragment f on User { name }`,
+	}, meterOptions)
+	if len(r.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", r.Errors)
+	}
+	if _, ok := r.Extensions["cost"]; ok {
+		t.Fatal("unexpected extension")
+	}
+	if reported == nil {
+		t.Fatal("measurement is not reported")
+	}
+	if reported.EstimatedCost != 2 || reported.ActualCost != 3 {
+		t.Fatalf("unexpected cost: %+v", reported)
+	}
+}
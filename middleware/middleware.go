@@ -0,0 +1,370 @@
+/*
+Package middleware provides net/http middleware which rejects expensive
+GraphQL requests before execution.
+
+It parses GraphQL requests as graphql-go/handler does ("query" in URLs, and
+JSON, form and "application/graphql" bodies of POST, plus batched arrays),
+rejects ones which can't be parsed, runs cost analysis with variables and
+operation name of each request, and responds GraphQL errors when they
+exceed limits. Otherwise computed costs are passed to the next
+handler via context.Context, see FromContext. Sizes of request bodies are
+limited by Options.MaxBodySize.
+*/
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/koron-go/gqlcost"
+	"github.com/koron-go/gqlcost/ratelimit"
+)
+
+const (
+	// CodeBatchRejected is a code for requests in a batch which are
+	// rejected because other requests in the batch are rejected.
+	CodeBatchRejected = "BATCH_REJECTED"
+
+	// CodeBodyTooLarge is a code for requests which bodies exceed
+	// Options.MaxBodySize.
+	CodeBodyTooLarge = "BODY_TOO_LARGE"
+
+	// CodeBadRequest is a code for requests which can't be parsed.
+	CodeBadRequest = "BAD_REQUEST"
+)
+
+// DefaultMaxBodySize is the default value of Options.MaxBodySize.
+const DefaultMaxBodySize = 1 << 20
+
+// Options provides options for the middleware.
+type Options struct {
+	// Schema is the schema to analyze requests (required).
+	Schema *graphql.Schema
+
+	// Analysis provides options for cost analysis. Valiables and
+	// OperationName are given by each request.
+	Analysis gqlcost.AnalysisOptions
+
+	// StatusCode is HTTP status code of responses for requests which are
+	// rejected by cost analysis. Default is 200 (http.StatusOK).
+	StatusCode int
+
+	// Limiter enables rate limiting when it is not nil. Total cost of
+	// requests is deducted from the budget of the client, and requests are
+	// rejected with 429 (http.StatusTooManyRequests) when it is empty.
+	Limiter ratelimit.Limiter
+
+	// Key returns a key to identify the client for Limiter. Default is the
+	// host of http.Request.RemoteAddr.
+	Key func(*http.Request) string
+
+	// MaxBodySize is the maximum size of request bodies in bytes. Requests
+	// with larger bodies are rejected with 413
+	// (http.StatusRequestEntityTooLarge). Default is DefaultMaxBodySize.
+	MaxBodySize int64
+}
+
+func (o Options) statusCode() int {
+	if o.StatusCode == 0 {
+		return http.StatusOK
+	}
+	return o.StatusCode
+}
+
+func (o Options) maxBodySize() int64 {
+	if o.MaxBodySize == 0 {
+		return DefaultMaxBodySize
+	}
+	return o.MaxBodySize
+}
+
+func (o Options) key(r *http.Request) string {
+	if o.Key != nil {
+		return o.Key(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Request is a GraphQL request over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts "variables" encoded
+// as a JSON string too, as graphql-go/handler does.
+func (req *Request) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Query         string          `json:"query"`
+		Variables     json.RawMessage `json:"variables"`
+		OperationName string          `json:"operationName"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*req = Request{Query: v.Query, OperationName: v.OperationName}
+	vars := []byte(v.Variables)
+	if len(vars) > 0 && vars[0] == '"' {
+		var s string
+		if err := json.Unmarshal(vars, &s); err != nil {
+			return err
+		}
+		vars = []byte(s)
+	}
+	if len(vars) == 0 {
+		return nil
+	}
+	return json.Unmarshal(vars, &req.Variables)
+}
+
+// response is a GraphQL response for rejected requests. It has no "data"
+// because requests are rejected before execution.
+type response struct {
+	Errors []gqlerrors.FormattedError `json:"errors"`
+}
+
+type contextKey struct{}
+
+// FromContext returns results of cost analysis for each request, which are
+// put by the middleware. A batch has results in same order of requests.
+func FromContext(ctx context.Context) ([]gqlcost.Result, bool) {
+	results, ok := ctx.Value(contextKey{}).([]gqlcost.Result)
+	return results, ok
+}
+
+// New creates a middleware which rejects expensive GraphQL requests.
+//
+// Requests which can't be parsed are rejected with 400
+// (http.StatusBadRequest), because they can't be analyzed. Requests without
+// queries are passed to the next handler as is, to respond errors for them.
+func New(opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, opts.maxBodySize())
+			}
+			reqs, batch, err := parseRequest(r)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeResponses(w, http.StatusRequestEntityTooLarge, false, [][]gqlerrors.FormattedError{
+					{formatError(errBodyTooLarge{limit: tooLarge.Limit})},
+				})
+				return
+			}
+			if err != nil {
+				writeResponses(w, http.StatusBadRequest, false, [][]gqlerrors.FormattedError{
+					{formatError(errBadRequest{err: err})},
+				})
+				return
+			}
+			if len(reqs) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			results, errs, rejected := analyze(opts, reqs)
+			if rejected {
+				writeResponses(w, opts.statusCode(), batch, errs)
+				return
+			}
+			if opts.Limiter != nil {
+				var total gqlcost.Result
+				for _, r := range results {
+					total.Cost += r.Cost
+				}
+				if err := ratelimit.Check(opts.Limiter, opts.key(r), total); err != nil {
+					var rlErr *ratelimit.Error
+					if errors.As(err, &rlErr) && rlErr.RetryAfter > 0 {
+						w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rlErr.RetryAfter.Seconds()))))
+					}
+					for i := range errs {
+						errs[i] = []gqlerrors.FormattedError{formatError(err)}
+					}
+					writeResponses(w, http.StatusTooManyRequests, batch, errs)
+					return
+				}
+			}
+			ctx := context.WithValue(r.Context(), contextKey{}, results)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// analyze runs cost analysis for each request. errs has errors for each
+// request, and rejected is true when some requests have errors.
+func analyze(opts Options, reqs []Request) (results []gqlcost.Result, errs [][]gqlerrors.FormattedError, rejected bool) {
+	results = make([]gqlcost.Result, len(reqs))
+	errs = make([][]gqlerrors.FormattedError, len(reqs))
+	for i, req := range reqs {
+		doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+		if err != nil {
+			// syntax errors are reported by the next handler.
+			continue
+		}
+		ao := opts.Analysis
+		ao.Valiables = req.Variables
+		ao.OperationName = req.OperationName
+		r, err := gqlcost.Analyze(opts.Schema, doc, ao)
+		results[i] = r
+		if err == nil {
+			continue
+		}
+		rejected = true
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				errs[i] = append(errs[i], gqlerrors.FormatError(err))
+			}
+			continue
+		}
+		errs[i] = append(errs[i], gqlerrors.FormatError(err))
+	}
+	if !rejected {
+		return results, errs, false
+	}
+	for i := range errs {
+		if len(errs[i]) == 0 {
+			errs[i] = []gqlerrors.FormattedError{
+				formatError(errBatchRejected{}),
+			}
+		}
+	}
+	return results, errs, true
+}
+
+type errBatchRejected struct{}
+
+func (errBatchRejected) Error() string {
+	return "The request is rejected because other requests in the batch are rejected"
+}
+
+func (errBatchRejected) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": CodeBatchRejected}
+}
+
+type errBodyTooLarge struct {
+	limit int64
+}
+
+func (e errBodyTooLarge) Error() string {
+	return fmt.Sprintf("The request body exceeds the maximum size of %d bytes", e.limit)
+}
+
+func (errBodyTooLarge) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": CodeBodyTooLarge}
+}
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return "The request can't be parsed: " + e.err.Error()
+}
+
+func (errBadRequest) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": CodeBadRequest}
+}
+
+// formatError formats an error with its extensions.
+func formatError(err error) gqlerrors.FormattedError {
+	return gqlerrors.FormatError(gqlerrors.NewError(err.Error(), nil, "", nil, []int{}, err))
+}
+
+func writeResponses(w http.ResponseWriter, status int, batch bool, errs [][]gqlerrors.FormattedError) {
+	var v interface{}
+	if batch {
+		resps := make([]response, len(errs))
+		for i, e := range errs {
+			resps[i] = response{Errors: e}
+		}
+		v = resps
+	} else {
+		v = response{Errors: errs[0]}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// parseRequest parses GraphQL requests in r as graphql-go/handler does:
+// "query" in the URL takes precedence for any methods, and otherwise only
+// bodies of POST requests are read. batch is true when the body is a JSON
+// array. The body of r is restored to be read by the next handler.
+func parseRequest(r *http.Request) (reqs []Request, batch bool, err error) {
+	if values := r.URL.Query(); values.Get("query") != "" {
+		req, err := requestFromValues(values)
+		if err != nil {
+			return nil, false, err
+		}
+		return []Request{req}, false, nil
+	}
+	if r.Method != http.MethodPost || r.Body == nil {
+		return nil, false, nil
+	}
+
+	b, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return nil, false, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/graphql":
+		return []Request{{Query: string(b)}}, false, nil
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(b))
+		if err != nil {
+			return nil, false, err
+		}
+		if values.Get("query") == "" {
+			return nil, false, nil
+		}
+		req, err := requestFromValues(values)
+		if err != nil {
+			return nil, false, err
+		}
+		return []Request{req}, false, nil
+	}
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		if err := json.Unmarshal(b, &reqs); err != nil {
+			return nil, false, err
+		}
+		return reqs, true, nil
+	}
+	var req Request
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, false, err
+	}
+	return []Request{req}, false, nil
+}
+
+func requestFromValues(values url.Values) (Request, error) {
+	req := Request{
+		Query:         values.Get("query"),
+		OperationName: values.Get("operationName"),
+	}
+	if s := values.Get("variables"); s != "" {
+		if err := json.Unmarshal([]byte(s), &req.Variables); err != nil {
+			return Request{}, err
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,268 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/koron-go/gqlcost"
+	"github.com/koron-go/gqlcost/ratelimit"
+)
+
+const testSDL = `
+directive @cost(complexity: Int, multipliers: [String], useMultipliers: Boolean) on OBJECT | FIELD_DEFINITION
+
+type Query {
+  users(first: Int): [User] @cost(complexity: 1, multipliers: ["first"])
+}
+
+type User {
+  name: String @cost(complexity: 1)
+}
+`
+
+type testResponse struct {
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newTestHandler(t *testing.T, opts Options) (http.Handler, *[]gqlcost.Result, *string) {
+	t.Helper()
+	schema, costMap, err := gqlcost.BuildSchema(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Schema = schema
+	opts.Analysis.CostMap = costMap
+	var (
+		results []gqlcost.Result
+		body    string
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results, _ = FromContext(r.Context())
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"data":{}}`))
+	})
+	return New(opts)(next), &results, &body
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	h, results, body := newTestHandler(t, Options{
+		Analysis: gqlcost.AnalysisOptions{MaximumCost: 100},
+	})
+
+	// POST JSON with variables: accepted.
+	reqBody := `{"query":"query Q($n: Int) { users(first: $n) { name } }","variables":{"n":10},"operationName":"Q"}`
+	rec := serve(h, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(reqBody)))
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"data":{}}` {
+		t.Fatalf("unexpected response: %d %s", rec.Code, rec.Body)
+	}
+	if len(*results) != 1 || (*results)[0].Cost != 20 {
+		t.Fatalf("unexpected results: %+v", *results)
+	}
+	if *body != reqBody {
+		t.Fatalf("body is not restored: %s", *body)
+	}
+
+	// GET with variables: rejected.
+	q := url.Values{}
+	q.Set("query", `query Q($n: Int) { users(first: $n) { name } }`)
+	q.Set("variables", `{"n": 100}`)
+	rec = serve(h, httptest.NewRequest(http.MethodGet, "/graphql?"+q.Encode(), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != gqlcost.CodeCostLimitExceeded {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), `"data"`) {
+		t.Fatalf("data should be absent: %s", rec.Body)
+	}
+
+	// application/graphql: accepted.
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{ users(first: 3) { name } }`))
+	r.Header.Set("Content-Type", "application/graphql")
+	rec = serve(h, r)
+	if rec.Code != http.StatusOK || len(*results) != 1 || (*results)[0].Cost != 6 {
+		t.Fatalf("unexpected response: %d %s %+v", rec.Code, rec.Body, *results)
+	}
+
+	// syntax errors are passed to the next handler.
+	rec = serve(h, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{"}`)))
+	if rec.Body.String() != `{"data":{}}` {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+}
+
+func TestMiddleware_Parse(t *testing.T) {
+	h, _, _ := newTestHandler(t, Options{
+		Analysis: gqlcost.AnalysisOptions{MaximumCost: 100},
+	})
+	expensive := url.Values{}
+	expensive.Set("query", `{ users(first: 100) { name } }`)
+	cheap := `{"query":"{ users(first: 1) { name } }"}`
+
+	for _, tc := range []struct {
+		name string
+		r    *http.Request
+		code string
+	}{
+		// "query" in the URL takes precedence over the body.
+		{"POST with query in URL", httptest.NewRequest(http.MethodPost, "/graphql?"+expensive.Encode(), strings.NewReader(cheap)), gqlcost.CodeCostLimitExceeded},
+		// "query" in the URL is executed for any methods.
+		{"PUT with query in URL", httptest.NewRequest(http.MethodPut, "/graphql?"+expensive.Encode(), nil), gqlcost.CodeCostLimitExceeded},
+		// variables encoded as a JSON string.
+		{"string variables", httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"query Q($n: Int) { users(first: $n) { name } }","variables":"{\"n\":100}"}`)), gqlcost.CodeCostLimitExceeded},
+		{"invalid JSON", httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":`)), CodeBadRequest},
+		{"invalid variables", httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ users(first: 1) { name } }","variables":"{"}`)), CodeBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(h, tc.r)
+			var resp testResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%s: %s", err, rec.Body)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != tc.code {
+				t.Fatalf("unexpected response: %d %s", rec.Code, rec.Body)
+			}
+			if tc.code == CodeBadRequest && rec.Code != http.StatusBadRequest {
+				t.Errorf("unexpected status: %d", rec.Code)
+			}
+		})
+	}
+
+	// requests without queries are passed to the next handler.
+	rec := serve(h, httptest.NewRequest(http.MethodPut, "/graphql", strings.NewReader(cheap)))
+	if rec.Body.String() != `{"data":{}}` {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+}
+
+func TestMiddleware_Batch(t *testing.T) {
+	h, results, _ := newTestHandler(t, Options{
+		Analysis:   gqlcost.AnalysisOptions{MaximumCost: 100},
+		StatusCode: http.StatusTooManyRequests,
+	})
+
+	rec := serve(h, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`[
+		{"query": "{ users(first: 1) { name } }"},
+		{"query": "{ users(first: 2) { name } }"}
+	]`)))
+	if rec.Code != http.StatusOK || len(*results) != 2 || (*results)[1].Cost != 4 {
+		t.Fatalf("unexpected response: %d %s %+v", rec.Code, rec.Body, *results)
+	}
+
+	rec = serve(h, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`[
+		{"query": "{ users(first: 1) { name } }"},
+		{"query": "{ users(first: 100) { name } }"}
+	]`)))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	var resps []testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resps); err != nil {
+		t.Fatal(err)
+	}
+	if len(resps) != 2 {
+		t.Fatalf("unexpected responses: %s", rec.Body)
+	}
+	if code := resps[0].Errors[0].Extensions["code"]; code != CodeBatchRejected {
+		t.Errorf("unexpected code for #0: %v", code)
+	}
+	if code := resps[1].Errors[0].Extensions["code"]; code != gqlcost.CodeCostLimitExceeded {
+		t.Errorf("unexpected code for #1: %v", code)
+	}
+}
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	h, _, _ := newTestHandler(t, Options{
+		Limiter: ratelimit.NewTokenBucket(30, 1),
+		Key:     func(r *http.Request) string { return r.Header.Get("X-Client") },
+	})
+	newReq := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ users(first: 10) { name } }"}`))
+		r.Header.Set("X-Client", "a")
+		return r
+	}
+	if rec := serve(h, newReq()); rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d %s", rec.Code, rec.Body)
+	}
+	rec := serve(h, newReq())
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status: %d %s", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After is not set")
+	}
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != ratelimit.CodeRateLimited {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+}
+
+func TestMiddleware_MaxBodySize(t *testing.T) {
+	h, _, _ := newTestHandler(t, Options{MaxBodySize: 32})
+	rec := serve(h, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ users(first: 10) { name } }"}`)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status: %d %s", rec.Code, rec.Body)
+	}
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != CodeBodyTooLarge {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+}
+
+func TestMiddleware_FanOut(t *testing.T) {
+	h, _, _ := newTestHandler(t, Options{
+		Analysis: gqlcost.AnalysisOptions{MaximumCost: 100, BreakdownInErrors: true},
+	})
+	// 2^24 fields after expanding fragments.
+	var b strings.Builder
+	b.WriteString("query { users(first: 1) { ...f0 } }")
+	for i := 0; i < 24; i++ {
+		fmt.Fprintf(&b, " fragment f%d on User { ...f%d ...f%d }", i, i+1, i+1)
+	}
+	b.WriteString(" fragment f24 on User { name }")
+	reqBody, err := json.Marshal(Request{Query: b.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	rec := serve(h, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(reqBody)))
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("too slow: %s", d)
+	}
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != gqlcost.CodeCostLimitExceeded || resp.Errors[0].Extensions["actualCost"] != float64(1<<24+1) {
+		t.Fatalf("unexpected response: %.200s", rec.Body)
+	}
+}
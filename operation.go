@@ -0,0 +1,28 @@
+package gqlcost
+
+// OperationOptions provides a cost policy for a type of operations:
+// mutation or subscription.
+type OperationOptions struct {
+	// MaximumCost is the maximum cost of each operation of the type. It
+	// overrides AnalysisOptions.MaximumCost when it is not zero.
+	MaximumCost int
+
+	// BaseCost is a cost which is added to each operation of the type.
+	// It isn't included in the breakdown.
+	BaseCost int
+}
+
+// operationOptions returns OperationOptions for a type of operations.
+func (opts AnalysisOptions) operationOptions(operation string) OperationOptions {
+	var oo OperationOptions
+	switch operation {
+	case "mutation":
+		oo = opts.Mutation
+	case "subscription":
+		oo = opts.Subscription
+	}
+	if oo.MaximumCost == 0 {
+		oo.MaximumCost = opts.MaximumCost
+	}
+	return oo
+}
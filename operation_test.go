@@ -0,0 +1,49 @@
+package gqlcost
+
+import "testing"
+
+func TestOperationOptions_BaseCost(t *testing.T) {
+	opts := AnalysisOptions{
+		DefaultCost:  5,
+		Mutation:     OperationOptions{BaseCost: 10},
+		Subscription: OperationOptions{BaseCost: 2},
+	}
+	testCost(t, `query { defaultCost }`, opts, 5)
+	testCost(t, `mutation { setName(name: "foo") }`, opts, 15)
+	testCost(t, `subscription { nameChanged }`, opts, 7)
+}
+
+func TestOperationOptions_MaximumCost(t *testing.T) {
+	opts := AnalysisOptions{
+		MaximumCost:  20,
+		DefaultCost:  5,
+		Mutation:     OperationOptions{BaseCost: 20},
+		Subscription: OperationOptions{MaximumCost: 4},
+	}
+	testErrs(t, `query { defaultCost }`, opts)
+	testErrs(t, `mutation { setName(name: "foo") }`, opts,
+		"The query exceeds the maximum cost of 20. Actual cost is 25")
+	testErrs(t, `subscription { nameChanged }`, opts,
+		"The query exceeds the maximum cost of 4. Actual cost is 5")
+}
+
+func TestOperationOptions_MultipleOperations(t *testing.T) {
+	ca := testErrs(t, `
+		query Q { defaultCost }
+		mutation M { setName(name: "foo") }`,
+		AnalysisOptions{
+			MaximumCost: 100,
+			DefaultCost: 5,
+			Mutation:    OperationOptions{BaseCost: 10, MaximumCost: 12},
+		},
+		"The query exceeds the maximum cost of 12. Actual cost is 15")
+	if len(ca.operations) != 2 {
+		t.Fatalf("unexpected operations: %+v", ca.operations)
+	}
+	if c := ca.operations[0].Cost; c != 5 {
+		t.Errorf("unexpected cost of Q: want=5 got=%d", c)
+	}
+	if c := ca.operations[1].Cost; c != 15 {
+		t.Errorf("unexpected cost of M: want=15 got=%d", c)
+	}
+}
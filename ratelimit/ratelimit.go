@@ -0,0 +1,175 @@
+/*
+Package ratelimit provides cost based rate limiting for GraphQL requests.
+Each client has a budget of cost over time, and each operation's cost which
+is computed by gqlcost is deducted from it.
+*/
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/koron-go/gqlcost"
+)
+
+// CodeRateLimited is a code for Error, which is put in "code" of extensions.
+const CodeRateLimited = "RATE_LIMITED"
+
+// Status provides a result of Limiter.Take.
+type Status struct {
+	// Allowed is true when the cost is deducted from the budget.
+	Allowed bool
+
+	// Limit is capacity of the budget.
+	Limit int
+
+	// Remaining is remaining budget after Take.
+	Remaining int
+
+	// RetryAfter is a duration to wait until the cost can be deducted.
+	// It is zero when Allowed is true, or the cost exceeds Limit.
+	RetryAfter time.Duration
+}
+
+// Limiter limits cost of requests for each client.
+type Limiter interface {
+	// Take deducts cost from the budget of the client identified by key.
+	Take(key string, cost int) Status
+}
+
+// TokenBucket is an in-memory Limiter which uses token bucket algorithm.
+// Each client has a bucket which has Capacity tokens at most, and it is
+// refilled Rate tokens per second.
+type TokenBucket struct {
+	capacity int
+	rate     float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var _ Limiter = (*TokenBucket)(nil)
+
+// NewTokenBucket creates a new TokenBucket, which has capacity tokens at
+// most for each client and refills rate tokens per second.
+func NewTokenBucket(capacity int, rate float64) *TokenBucket {
+	return &TokenBucket{
+		capacity: capacity,
+		rate:     rate,
+		buckets:  map[string]*bucket{},
+		now:      time.Now,
+	}
+}
+
+// Take deducts cost from the bucket of the client identified by key.
+// Negative costs are rejected, because they would add tokens.
+func (tb *TokenBucket) Take(key string, cost int) Status {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	now := tb.now()
+	tb.sweep(now)
+
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(tb.capacity), last: now}
+	}
+	tb.refill(b, now)
+
+	st := Status{Limit: tb.capacity}
+	switch {
+	case cost < 0:
+	case float64(cost) <= b.tokens:
+		b.tokens -= float64(cost)
+		st.Allowed = true
+	case cost <= tb.capacity && tb.rate > 0:
+		wait := (float64(cost) - b.tokens) / tb.rate
+		st.RetryAfter = time.Duration(math.Ceil(wait * float64(time.Second)))
+	}
+	st.Remaining = int(b.tokens)
+	// full buckets are not kept, so clients which take nothing (ex. rejected
+	// or zero costs) don't grow buckets even when sweep doesn't run.
+	if !ok && b.tokens < float64(tb.capacity) {
+		tb.buckets[key] = b
+	}
+	return st
+}
+
+func (tb *TokenBucket) refill(b *bucket, now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(tb.capacity), b.tokens+elapsed*tb.rate)
+	}
+	b.last = now
+}
+
+// sweep removes buckets which are full, to avoid unbounded growth of
+// buckets. It runs at most once per the time to fill a bucket. Without
+// refilling (rate <= 0) it never runs, as no buckets get full again.
+func (tb *TokenBucket) sweep(now time.Time) {
+	if tb.rate <= 0 {
+		return
+	}
+	fill := time.Duration(float64(tb.capacity) / tb.rate * float64(time.Second))
+	if now.Sub(tb.lastSweep) < fill {
+		return
+	}
+	tb.lastSweep = now
+	for k, b := range tb.buckets {
+		tb.refill(b, now)
+		if b.tokens >= float64(tb.capacity) {
+			delete(tb.buckets, k)
+		}
+	}
+}
+
+// Error is an error when a client runs out of its budget. It implements
+// gqlerrors.ExtendedError.
+type Error struct {
+	Cost       int
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.RetryAfter <= 0 {
+		return fmt.Sprintf("The query cost %d exceeds the rate limit of %d", e.Cost, e.Limit)
+	}
+	return fmt.Sprintf("Rate limit exceeded. Retry after %s", e.RetryAfter)
+}
+
+// Extensions returns extensions for GraphQL errors.
+// "retryAfter" is in seconds.
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":       CodeRateLimited,
+		"cost":       e.Cost,
+		"limit":      e.Limit,
+		"remaining":  e.Remaining,
+		"retryAfter": int(math.Ceil(e.RetryAfter.Seconds())),
+	}
+}
+
+// Check deducts total cost of the analysis result from the budget of the
+// client identified by key. It returns *Error when the budget is empty.
+func Check(l Limiter, key string, r gqlcost.Result) error {
+	st := l.Take(key, r.Cost)
+	if st.Allowed {
+		return nil
+	}
+	return &Error{
+		Cost:       r.Cost,
+		Limit:      st.Limit,
+		Remaining:  st.Remaining,
+		RetryAfter: st.RetryAfter,
+	}
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/koron-go/gqlcost"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBucket(capacity int, rate float64) (*TokenBucket, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	tb := NewTokenBucket(capacity, rate)
+	tb.now = clock.now
+	return tb, clock
+}
+
+func TestTokenBucket(t *testing.T) {
+	tb, clock := newTestBucket(100, 10)
+
+	for i, tc := range []struct {
+		advance time.Duration
+		key     string
+		cost    int
+		exp     Status
+	}{
+		{0, "a", 60, Status{Allowed: true, Limit: 100, Remaining: 40}},
+		{0, "a", 60, Status{Limit: 100, Remaining: 40, RetryAfter: 2 * time.Second}},
+		{0, "b", 60, Status{Allowed: true, Limit: 100, Remaining: 40}},
+		{2 * time.Second, "a", 60, Status{Allowed: true, Limit: 100, Remaining: 0}},
+		{0, "a", 200, Status{Limit: 100, Remaining: 0}},
+		{time.Hour, "a", 10, Status{Allowed: true, Limit: 100, Remaining: 90}},
+		// negative costs don't add tokens.
+		{0, "a", -50, Status{Limit: 100, Remaining: 90}},
+		{0, "a", 100, Status{Limit: 100, Remaining: 90, RetryAfter: time.Second}},
+	} {
+		clock.advance(tc.advance)
+		st := tb.Take(tc.key, tc.cost)
+		if st != tc.exp {
+			t.Errorf("#%d unexpected status: want=%+v got=%+v", i, tc.exp, st)
+		}
+	}
+}
+
+func TestTokenBucket_Sweep(t *testing.T) {
+	tb, clock := newTestBucket(10, 1)
+	tb.Take("a", 5)
+	tb.Take("b", 5)
+	clock.advance(10 * time.Second)
+	tb.Take("c", 1)
+	if len(tb.buckets) != 1 {
+		t.Fatalf("full buckets should be removed: %d", len(tb.buckets))
+	}
+}
+
+func TestTokenBucket_NoRefill(t *testing.T) {
+	tb, clock := newTestBucket(10, 0)
+	for i := 0; i < 100; i++ {
+		clock.advance(time.Hour)
+		// rejected or zero costs keep buckets full.
+		tb.Take(fmt.Sprintf("rejected%d", i), 20)
+		tb.Take(fmt.Sprintf("zero%d", i), 0)
+	}
+	if len(tb.buckets) != 0 {
+		t.Fatalf("full buckets should not be kept: %d", len(tb.buckets))
+	}
+	tb.Take("a", 5)
+	clock.advance(time.Hour)
+	if st := tb.Take("a", 6); st.Allowed || st.Remaining != 5 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tb, _ := newTestBucket(10, 1)
+	if err := Check(tb, "a", gqlcost.Result{Cost: 8}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err := Check(tb, "a", gqlcost.Result{Cost: 8})
+	var re *Error
+	if !errors.As(err, &re) {
+		t.Fatalf("ratelimit.Error expected: %T", err)
+	}
+	if err.Error() != "Rate limit exceeded. Retry after 6s" {
+		t.Fatalf("unexpected message: %s", err)
+	}
+	ext := re.Extensions()
+	if ext["code"] != CodeRateLimited || ext["retryAfter"] != 6 || ext["remaining"] != 2 {
+		t.Fatalf("unexpected extensions: %+v", ext)
+	}
+}
@@ -0,0 +1,153 @@
+package gqlcost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// PersistedQuery is a query in a corpus, like persisted queries of clients.
+type PersistedQuery struct {
+	// ID identifies the query: a path of the file or a hash in a manifest.
+	ID string `json:"id"`
+
+	// Query is a document of the query.
+	Query string `json:"query"`
+}
+
+// LoadQueries loads query files (*.graphql and *.gql) under dir
+// recursively. IDs of queries are slash separated paths relative to dir.
+func LoadQueries(dir string) ([]PersistedQuery, error) {
+	var queries []PersistedQuery
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".graphql", ".gql":
+		default:
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		queries = append(queries, PersistedQuery{
+			ID:    filepath.ToSlash(rel),
+			Query: string(b),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return queries, nil
+}
+
+// LoadManifest loads a persisted query manifest, which is a JSON object
+// from hashes to queries. Queries are sorted by hashes.
+func LoadManifest(r io.Reader) ([]PersistedQuery, error) {
+	var m map[string]string
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("gqlcost: failed to decode manifest: %w", err)
+	}
+	queries := make([]PersistedQuery, 0, len(m))
+	for id, q := range m {
+		queries = append(queries, PersistedQuery{ID: id, Query: q})
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].ID < queries[j].ID
+	})
+	return queries, nil
+}
+
+// ReportEntry provides cost of an operation in a corpus.
+type ReportEntry struct {
+	// ID is ID of the query which contains the operation.
+	ID string `json:"id"`
+
+	// Name is name of the operation. It is empty for anonymous operation.
+	Name string `json:"name,omitempty"`
+
+	// Operation is type of the operation: "query", "mutation" or
+	// "subscription".
+	Operation string `json:"operation,omitempty"`
+
+	// Cost is computed cost of the operation.
+	Cost int `json:"cost"`
+
+	// Exceeded is true when Cost exceeds the maximum cost for the operation.
+	Exceeded bool `json:"exceeded"`
+
+	// Error is an error of the query, ex. syntax errors. Operation and Cost
+	// may be empty when it is available.
+	Error string `json:"error,omitempty"`
+}
+
+// Report computes cost of each operation in queries. Queries are validated
+// with graphql.SpecifiedRules, and exceeding the maximum cost in opts is
+// reported by ReportEntry.Exceeded instead of Error.
+func Report(schema *graphql.Schema, queries []PersistedQuery, opts AnalysisOptions) []ReportEntry {
+	analysisOpts := opts
+	analysisOpts.MaximumCost = 0
+	analysisOpts.Mutation.MaximumCost = 0
+	analysisOpts.Subscription.MaximumCost = 0
+	analysisOpts.Breakdown = false
+	analysisOpts.BreakdownInErrors = false
+
+	var entries []ReportEntry
+	for _, q := range queries {
+		doc, err := parser.Parse(parser.ParseParams{Source: q.Query})
+		if err != nil {
+			entries = append(entries, ReportEntry{ID: q.ID, Error: err.Error()})
+			continue
+		}
+		if vr := graphql.ValidateDocument(schema, doc, graphql.SpecifiedRules); !vr.IsValid {
+			msgs := make([]string, 0, len(vr.Errors))
+			for _, err := range vr.Errors {
+				msgs = append(msgs, err.Message)
+			}
+			entries = append(entries, ReportEntry{ID: q.ID, Error: strings.Join(msgs, "; ")})
+			continue
+		}
+		r, err := Analyze(schema, doc, analysisOpts)
+		var errMsg string
+		if err != nil {
+			errMsg = strings.ReplaceAll(err.Error(), "\n", "; ")
+		}
+		if len(r.Operations) == 0 {
+			if errMsg == "" {
+				errMsg = "no operations"
+			}
+			entries = append(entries, ReportEntry{ID: q.ID, Error: errMsg})
+			continue
+		}
+		for _, oc := range r.Operations {
+			max := opts.operationOptions(oc.Operation).MaximumCost
+			entries = append(entries, ReportEntry{
+				ID:        q.ID,
+				Name:      oc.Name,
+				Operation: oc.Operation,
+				Cost:      oc.Cost,
+				Exceeded:  max > 0 && oc.Cost > max,
+				Error:     errMsg,
+			})
+		}
+	}
+	return entries
+}
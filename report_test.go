@@ -0,0 +1,92 @@
+package gqlcost
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadQueries(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.graphql":     `query A { customCost }`,
+		"sub/b.gql":     `query B { defaultCost }`,
+		"sub/README.md": `not a query`,
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	queries, err := LoadQueries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []PersistedQuery{
+		{ID: "a.graphql", Query: `query A { customCost }`},
+		{ID: "sub/b.gql", Query: `query B { defaultCost }`},
+	}
+	if !reflect.DeepEqual(queries, exp) {
+		t.Fatalf("unexpected queries:\nwant=%+v\ngot=%+v", exp, queries)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	queries, err := LoadManifest(strings.NewReader(`{
+		"f00": "query B { defaultCost }",
+		"a12": "query A { customCost }"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []PersistedQuery{
+		{ID: "a12", Query: `query A { customCost }`},
+		{ID: "f00", Query: `query B { defaultCost }`},
+	}
+	if !reflect.DeepEqual(queries, exp) {
+		t.Fatalf("unexpected queries:\nwant=%+v\ngot=%+v", exp, queries)
+	}
+
+	if _, err := LoadManifest(strings.NewReader(`[]`)); err == nil {
+		t.Fatal("error expected for invalid manifest")
+	}
+}
+
+func TestReport(t *testing.T) {
+	queries := []PersistedQuery{
+		{ID: "q1", Query: `query A { customCost } query B { defaultCost }`},
+		{ID: "q2", Query: `mutation { setName(name: "foo") }`},
+		{ID: "q3", Query: `query { unknown }`},
+		{ID: "q4", Query: `query {`},
+	}
+	entries := Report(schema, queries, AnalysisOptions{
+		MaximumCost: 5,
+		DefaultCost: 1,
+		Mutation:    OperationOptions{BaseCost: 10, MaximumCost: 20},
+		CostMap: CostMap{
+			"Query":    {Fields: FieldsCost{"customCost": {Complexity: 8}}},
+			"Mutation": {Fields: FieldsCost{"setName": {Complexity: 3}}},
+		},
+	})
+	if len(entries) != 5 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	exp := []ReportEntry{
+		{ID: "q1", Name: "A", Operation: "query", Cost: 8, Exceeded: true},
+		{ID: "q1", Name: "B", Operation: "query", Cost: 0},
+		{ID: "q2", Operation: "mutation", Cost: 13},
+	}
+	if !reflect.DeepEqual(entries[:3], exp) {
+		t.Fatalf("unexpected entries:\nwant=%+v\ngot=%+v", exp, entries[:3])
+	}
+	for _, e := range entries[3:] {
+		if e.Error == "" {
+			t.Errorf("error expected: %+v", e)
+		}
+	}
+}
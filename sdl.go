@@ -0,0 +1,492 @@
+package gqlcost
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// BuildSchema builds a schema and a cost map from SDL.
+// The cost map is extracted from @cost directives in SDL.
+//
+// The cost map has also entries for list and non-null types of fields, see
+// CostMap.AddWrappedTypes.
+//
+// Fields of the schema have only default resolvers, and abstract types are
+// resolved by "__typename" value of map. So the schema is mainly for cost
+// analysis, not for execution.
+func BuildSchema(sdl string) (*graphql.Schema, CostMap, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: sdl})
+	if err != nil {
+		return nil, nil, err
+	}
+	return BuildSchemaFromDocument(doc)
+}
+
+// BuildSchemaFromDocument builds a schema and a cost map from parsed SDL
+// document. See BuildSchema for details.
+func BuildSchemaFromDocument(doc *ast.Document) (*graphql.Schema, CostMap, error) {
+	costMap, err := CostMapFromDocument(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := newSchemaBuilder()
+	if err := b.collect(doc); err != nil {
+		return nil, nil, err
+	}
+	if err := b.check(); err != nil {
+		return nil, nil, err
+	}
+	s, err := b.build()
+	if err != nil {
+		return nil, nil, err
+	}
+	costMap.AddWrappedTypes(s)
+	return s, costMap, nil
+}
+
+type schemaBuilder struct {
+	defs       map[string]ast.Node
+	order      []string
+	extensions map[string][]*ast.FieldDefinition
+	operations map[string]string
+
+	types map[string]graphql.Type
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	b := &schemaBuilder{
+		defs:       map[string]ast.Node{},
+		extensions: map[string][]*ast.FieldDefinition{},
+		operations: map[string]string{},
+		types:      map[string]graphql.Type{},
+	}
+	for _, s := range []*graphql.Scalar{
+		graphql.Int, graphql.Float, graphql.String, graphql.Boolean, graphql.ID,
+	} {
+		b.types[s.Name()] = s
+	}
+	return b
+}
+
+func (b *schemaBuilder) addDef(name *ast.Name, def ast.Node) error {
+	if name == nil {
+		return errors.New("type must be named")
+	}
+	if _, ok := b.defs[name.Value]; ok {
+		return fmt.Errorf("type %q is defined more than once", name.Value)
+	}
+	if _, ok := b.types[name.Value]; ok {
+		return fmt.Errorf("type %q is built-in", name.Value)
+	}
+	b.defs[name.Value] = def
+	b.order = append(b.order, name.Value)
+	return nil
+}
+
+func (b *schemaBuilder) collect(doc *ast.Document) error {
+	for _, def := range doc.Definitions {
+		var err error
+		switch def := def.(type) {
+		case *ast.ScalarDefinition:
+			err = b.addDef(def.Name, def)
+		case *ast.ObjectDefinition:
+			err = b.addDef(def.Name, def)
+		case *ast.InterfaceDefinition:
+			err = b.addDef(def.Name, def)
+		case *ast.UnionDefinition:
+			err = b.addDef(def.Name, def)
+		case *ast.EnumDefinition:
+			err = b.addDef(def.Name, def)
+		case *ast.InputObjectDefinition:
+			err = b.addDef(def.Name, def)
+		case *ast.TypeExtensionDefinition:
+			if def.Definition == nil || def.Definition.Name == nil {
+				continue
+			}
+			name := def.Definition.Name.Value
+			b.extensions[name] = append(b.extensions[name], def.Definition.Fields...)
+		case *ast.SchemaDefinition:
+			for _, ot := range def.OperationTypes {
+				if ot == nil || ot.Type == nil || ot.Type.Name == nil {
+					continue
+				}
+				b.operations[ot.Operation] = ot.Type.Name.Value
+			}
+		case *ast.OperationDefinition, *ast.FragmentDefinition:
+			return errors.New("SDL must not contain operations or fragments")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for name := range b.extensions {
+		if _, ok := b.defs[name].(*ast.ObjectDefinition); !ok {
+			return fmt.Errorf("cannot extend type %q which is not defined as object", name)
+		}
+	}
+	return nil
+}
+
+// check checks all references of types are defined, and they are input
+// types for arguments and fields of input objects, or output types for
+// fields.
+func (b *schemaBuilder) check() error {
+	checkRef := func(where string, t ast.Type, input bool) error {
+		name := namedTypeName(t)
+		if def, ok := b.defs[name]; ok {
+			switch def.(type) {
+			case *ast.ScalarDefinition, *ast.EnumDefinition:
+			case *ast.InputObjectDefinition:
+				if !input {
+					return fmt.Errorf("%s: type %q is not an output type", where, name)
+				}
+			default:
+				if input {
+					return fmt.Errorf("%s: type %q is not an input type", where, name)
+				}
+			}
+			return nil
+		}
+		if _, ok := b.types[name]; ok {
+			return nil
+		}
+		return fmt.Errorf("%s: unknown type %q", where, name)
+	}
+	checkFields := func(typeName string, fields []*ast.FieldDefinition) error {
+		for _, f := range fields {
+			if f == nil || f.Name == nil {
+				return fmt.Errorf("%s: field must be named", typeName)
+			}
+			where := typeName + "." + f.Name.Value
+			if err := checkRef(where, f.Type, false); err != nil {
+				return err
+			}
+			for _, a := range f.Arguments {
+				if a == nil || a.Name == nil {
+					return fmt.Errorf("%s: argument must be named", where)
+				}
+				if err := checkRef(where+"("+a.Name.Value+")", a.Type, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, name := range b.order {
+		switch def := b.defs[name].(type) {
+		case *ast.ObjectDefinition:
+			if err := checkFields(name, def.Fields); err != nil {
+				return err
+			}
+			if err := checkFields(name, b.extensions[name]); err != nil {
+				return err
+			}
+			for _, iface := range def.Interfaces {
+				if _, ok := b.defs[namedTypeName(iface)].(*ast.InterfaceDefinition); !ok {
+					return fmt.Errorf("%s: unknown interface %q", name, namedTypeName(iface))
+				}
+			}
+		case *ast.InterfaceDefinition:
+			if err := checkFields(name, def.Fields); err != nil {
+				return err
+			}
+		case *ast.UnionDefinition:
+			for _, t := range def.Types {
+				if _, ok := b.defs[namedTypeName(t)].(*ast.ObjectDefinition); !ok {
+					return fmt.Errorf("%s: unknown object type %q", name, namedTypeName(t))
+				}
+			}
+		case *ast.InputObjectDefinition:
+			for _, f := range def.Fields {
+				if f == nil || f.Name == nil {
+					return fmt.Errorf("%s: field must be named", name)
+				}
+				if err := checkRef(name+"."+f.Name.Value, f.Type, true); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (b *schemaBuilder) build() (*graphql.Schema, error) {
+	// create all types at first, their members are resolved lazily by
+	// thunks.
+	for _, name := range b.order {
+		switch def := b.defs[name].(type) {
+		case *ast.ScalarDefinition:
+			b.types[name] = graphql.NewScalar(graphql.ScalarConfig{
+				Name:         name,
+				Description:  description(def.Description),
+				Serialize:    func(v interface{}) interface{} { return v },
+				ParseValue:   func(v interface{}) interface{} { return v },
+				ParseLiteral: literalValue,
+			})
+		case *ast.EnumDefinition:
+			values := graphql.EnumValueConfigMap{}
+			for _, v := range def.Values {
+				if v == nil || v.Name == nil {
+					continue
+				}
+				values[v.Name.Value] = &graphql.EnumValueConfig{
+					Value:             v.Name.Value,
+					Description:       description(v.Description),
+					DeprecationReason: deprecationReason(v.Directives),
+				}
+			}
+			b.types[name] = graphql.NewEnum(graphql.EnumConfig{
+				Name:        name,
+				Description: description(def.Description),
+				Values:      values,
+			})
+		case *ast.ObjectDefinition:
+			b.types[name] = graphql.NewObject(graphql.ObjectConfig{
+				Name:        name,
+				Description: description(def.Description),
+				Interfaces:  b.interfacesThunk(def.Interfaces),
+				Fields:      b.fieldsThunk(append(copyFieldDefs(def.Fields), b.extensions[name]...)),
+			})
+		case *ast.InterfaceDefinition:
+			b.types[name] = graphql.NewInterface(graphql.InterfaceConfig{
+				Name:        name,
+				Description: description(def.Description),
+				Fields:      b.fieldsThunk(def.Fields),
+				ResolveType: b.resolveType,
+			})
+		case *ast.UnionDefinition:
+			b.types[name] = graphql.NewUnion(graphql.UnionConfig{
+				Name:        name,
+				Description: description(def.Description),
+				Types:       b.unionTypesThunk(def.Types),
+				ResolveType: b.resolveType,
+			})
+		case *ast.InputObjectDefinition:
+			b.types[name] = graphql.NewInputObject(graphql.InputObjectConfig{
+				Name:        name,
+				Description: description(def.Description),
+				Fields:      b.inputFieldsThunk(def.Fields),
+			})
+		}
+	}
+
+	var (
+		config graphql.SchemaConfig
+		err    error
+	)
+	if config.Query, err = b.operationType("query", "Query", true); err != nil {
+		return nil, err
+	}
+	if config.Mutation, err = b.operationType("mutation", "Mutation", false); err != nil {
+		return nil, err
+	}
+	if config.Subscription, err = b.operationType("subscription", "Subscription", false); err != nil {
+		return nil, err
+	}
+	for _, name := range b.order {
+		config.Types = append(config.Types, b.types[name])
+	}
+	s, err := graphql.NewSchema(config)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (b *schemaBuilder) operationType(op, defaultName string, required bool) (*graphql.Object, error) {
+	name, ok := b.operations[op]
+	if !ok {
+		name = defaultName
+	}
+	t, found := b.types[name]
+	if !found {
+		if ok || required {
+			return nil, fmt.Errorf("%s type %q is not defined", op, name)
+		}
+		return nil, nil
+	}
+	obj, isObj := t.(*graphql.Object)
+	if !isObj {
+		return nil, fmt.Errorf("%s type %q must be object", op, name)
+	}
+	return obj, nil
+}
+
+func (b *schemaBuilder) typeRef(t ast.Type) graphql.Type {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return graphql.NewNonNull(b.typeRef(t.Type))
+	case *ast.List:
+		return graphql.NewList(b.typeRef(t.Type))
+	default:
+		return b.types[namedTypeName(t)]
+	}
+}
+
+func (b *schemaBuilder) fieldsThunk(defs []*ast.FieldDefinition) graphql.FieldsThunk {
+	return func() graphql.Fields {
+		fields := graphql.Fields{}
+		for _, f := range defs {
+			args := graphql.FieldConfigArgument{}
+			for _, a := range f.Arguments {
+				ttype, _ := b.typeRef(a.Type).(graphql.Input)
+				args[a.Name.Value] = &graphql.ArgumentConfig{
+					Type:         ttype,
+					DefaultValue: valueFromAST(a.DefaultValue, ttype, nil),
+					Description:  description(a.Description),
+				}
+			}
+			ttype, _ := b.typeRef(f.Type).(graphql.Output)
+			fields[f.Name.Value] = &graphql.Field{
+				Name:              f.Name.Value,
+				Type:              ttype,
+				Args:              args,
+				Description:       description(f.Description),
+				DeprecationReason: deprecationReason(f.Directives),
+			}
+		}
+		return fields
+	}
+}
+
+func (b *schemaBuilder) inputFieldsThunk(defs []*ast.InputValueDefinition) graphql.InputObjectConfigFieldMapThunk {
+	return func() graphql.InputObjectConfigFieldMap {
+		fields := graphql.InputObjectConfigFieldMap{}
+		for _, f := range defs {
+			ttype, _ := b.typeRef(f.Type).(graphql.Input)
+			fields[f.Name.Value] = &graphql.InputObjectFieldConfig{
+				Type:         ttype,
+				DefaultValue: valueFromAST(f.DefaultValue, ttype, nil),
+				Description:  description(f.Description),
+			}
+		}
+		return fields
+	}
+}
+
+func (b *schemaBuilder) interfacesThunk(names []*ast.Named) graphql.InterfacesThunk {
+	return func() []*graphql.Interface {
+		var ifaces []*graphql.Interface
+		for _, n := range names {
+			if iface, ok := b.types[namedTypeName(n)].(*graphql.Interface); ok {
+				ifaces = append(ifaces, iface)
+			}
+		}
+		return ifaces
+	}
+}
+
+func (b *schemaBuilder) unionTypesThunk(names []*ast.Named) graphql.UnionTypesThunk {
+	return func() []*graphql.Object {
+		var objs []*graphql.Object
+		for _, n := range names {
+			if obj, ok := b.types[namedTypeName(n)].(*graphql.Object); ok {
+				objs = append(objs, obj)
+			}
+		}
+		return objs
+	}
+}
+
+func (b *schemaBuilder) resolveType(p graphql.ResolveTypeParams) *graphql.Object {
+	m, ok := p.Value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	name, ok := m["__typename"].(string)
+	if !ok {
+		return nil
+	}
+	obj, _ := b.types[name].(*graphql.Object)
+	return obj
+}
+
+func copyFieldDefs(src []*ast.FieldDefinition) []*ast.FieldDefinition {
+	dst := make([]*ast.FieldDefinition, len(src))
+	copy(dst, src)
+	return dst
+}
+
+func namedTypeName(t ast.Type) string {
+	for {
+		switch x := t.(type) {
+		case *ast.NonNull:
+			t = x.Type
+		case *ast.List:
+			t = x.Type
+		case *ast.Named:
+			if x.Name == nil {
+				return ""
+			}
+			return x.Name.Value
+		default:
+			return ""
+		}
+	}
+}
+
+func description(v *ast.StringValue) string {
+	if v == nil {
+		return ""
+	}
+	return v.Value
+}
+
+func deprecationReason(directives []*ast.Directive) string {
+	for _, d := range directives {
+		if d == nil || d.Name == nil || d.Name.Value != "deprecated" {
+			continue
+		}
+		for _, arg := range d.Arguments {
+			if arg == nil || arg.Name == nil || arg.Name.Value != "reason" {
+				continue
+			}
+			if s, ok := arg.Value.(*ast.StringValue); ok {
+				return s.Value
+			}
+		}
+		return graphql.DefaultDeprecationReason
+	}
+	return ""
+}
+
+// literalValue converts a literal value to Go's value.
+func literalValue(v ast.Value) interface{} {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		if n, err := strconv.Atoi(v.Value); err == nil {
+			return n
+		}
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.ListValue:
+		values := make([]interface{}, 0, len(v.Values))
+		for _, item := range v.Values {
+			values = append(values, literalValue(item))
+		}
+		return values
+	case *ast.ObjectValue:
+		obj := map[string]interface{}{}
+		for _, f := range v.Fields {
+			if f == nil || f.Name == nil {
+				continue
+			}
+			obj[f.Name.Value] = literalValue(f.Value)
+		}
+		return obj
+	}
+	return nil
+}
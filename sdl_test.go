@@ -0,0 +1,101 @@
+package gqlcost
+
+import (
+	"testing"
+)
+
+const testSDL = `
+schema {
+	query: Root
+}
+
+directive @cost(complexity: Int, multipliers: [String], useMultipliers: Boolean) on OBJECT | FIELD_DEFINITION
+
+scalar Date
+
+enum Order { ASC DESC }
+
+input Filter {
+	name: String
+	order: Order = ASC
+}
+
+interface Node {
+	id: ID!
+}
+
+type User implements Node {
+	id: ID!
+	name: String
+	createdAt: Date
+	posts(limit: Int = 10, filter: Filter): [Post] @cost(complexity: 2, multipliers: ["limit"])
+}
+
+type Post implements Node {
+	id: ID!
+	title: String @cost(complexity: 1)
+}
+
+union SearchResult = User | Post
+
+type Root {
+	node(id: ID!): Node
+	users(limit: Int): [User] @cost(complexity: 1, multipliers: ["limit"])
+	search(text: String): [SearchResult]
+}
+`
+
+func TestBuildSchema(t *testing.T) {
+	s, m, err := BuildSchema(testSDL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if s.QueryType() == nil || s.QueryType().Name() != "Root" {
+		t.Fatalf("unexpected query type: %+v", s.QueryType())
+	}
+	if s.MutationType() != nil {
+		t.Fatalf("mutation type should be nil: %+v", s.MutationType())
+	}
+	doc := parseQuery(t, `
+		query {
+			users(limit: 5) {
+				name
+				posts { title }
+			}
+		}`)
+	r, err := Analyze(s, doc, AnalysisOptions{CostMap: m})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// users: 1*5, posts: 2*5*10, title: 1*5*10
+	if r.Cost != 155 {
+		t.Fatalf("wrong cost: want=%d got=%d", 155, r.Cost)
+	}
+}
+
+func TestBuildSchema_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		sdl string
+		err string
+	}{
+		{`type Foo { a: Int }`, `query type "Query" is not defined`},
+		{`type Query { a: Bar }`, `Query.a: unknown type "Bar"`},
+		{`type Query { a(x: Bar): Int }`, `Query.a(x): unknown type "Bar"`},
+		{`type Query { a(x: User): Int } type User { b: Int }`, `Query.a(x): type "User" is not an input type`},
+		{`type Query { a: Int } input I { b: User } type User { b: Int }`, `I.b: type "User" is not an input type`},
+		{`type Query { a: I } input I { b: Int }`, `Query.a: type "I" is not an output type`},
+		{`type Query { a: Int } type Query { b: Int }`, `type "Query" is defined more than once`},
+		{`type Query implements Foo { a: Int }`, `Query: unknown interface "Foo"`},
+		{`type Query { a: Int } extend type Foo { b: Int }`, `cannot extend type "Foo" which is not defined as object`},
+		{`schema { query: Foo } scalar Foo`, `query type "Foo" must be object`},
+	} {
+		_, _, err := BuildSchema(tc.sdl)
+		if err == nil {
+			t.Errorf("error expected for %s", tc.sdl)
+			continue
+		}
+		if err.Error() != tc.err {
+			t.Errorf("unexpected error:\nwant=%s\ngot=%s", tc.err, err)
+		}
+	}
+}
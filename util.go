@@ -1,8 +1,12 @@
 package gqlcost
 
 import (
+	"math"
 	"reflect"
 	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
 )
 
 func toNumber(v interface{}) (int, bool) {
@@ -43,6 +47,23 @@ func toNumber(v interface{}) (int, bool) {
 	return 0, false
 }
 
+// addCost returns a+b, or math.MaxInt when it overflows. Costs can be huge
+// when fragments are spread many times.
+func addCost(a, b int) int {
+	if a > 0 && b > 0 && a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// mulCost returns a*b, or math.MaxInt when it overflows.
+func mulCost(a, b int) int {
+	if a > 0 && b > 0 && a > math.MaxInt/b {
+		return math.MaxInt
+	}
+	return a * b
+}
+
 func maxCost(costs []int) int {
 	n := len(costs)
 	switch n {
@@ -76,3 +97,59 @@ func typName(typDef interface{}) string {
 	}
 	return ""
 }
+
+func operationName(od *ast.OperationDefinition) string {
+	if od == nil || od.Name == nil {
+		return ""
+	}
+	return od.Name.Value
+}
+
+// selectionSetName returns a name of the node which has a selection set,
+// for messages.
+func selectionSetName(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.OperationDefinition:
+		if name := operationName(n); name != "" {
+			return n.Operation + " " + name
+		}
+		return n.Operation
+	case *ast.Field:
+		if n.Alias != nil && n.Alias.Value != "" {
+			return n.Alias.Value
+		}
+		if n.Name != nil {
+			return n.Name.Value
+		}
+	case *ast.FragmentDefinition:
+		if n.Name != nil {
+			return "fragment " + n.Name.Value
+		}
+	case *ast.InlineFragment:
+		if n.TypeCondition != nil && n.TypeCondition.Name != nil {
+			return "... on " + n.TypeCondition.Name.Value
+		}
+		return "..."
+	}
+	return ""
+}
+
+func typDefToType(typDef interface{}) graphql.Type {
+	t, _ := typDef.(graphql.Type)
+	return t
+}
+
+// selectionDirectives returns directives of a field or a fragment.
+func selectionDirectives(sel ast.Selection) []*ast.Directive {
+	switch n := sel.(type) {
+	case *ast.Field:
+		return n.Directives
+	case *ast.FragmentSpread:
+		return n.Directives
+	case *ast.InlineFragment:
+		if n != nil {
+			return n.Directives
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package gqlcost
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Validate checks the CostMap against the schema. It reports unknown types,
+// unknown fields, names of multipliers which are not arguments of the
+// field, multipliers on non-list fields, and unknown strategies.
+func (m CostMap) Validate(schema *graphql.Schema) []error {
+	var errs []error
+	typNames := make([]string, 0, len(m))
+	for name := range m {
+		typNames = append(typNames, name)
+	}
+	sort.Strings(typNames)
+
+	for _, typName := range typNames {
+		tc := m[typName]
+		t := schema.Type(strings.Trim(typName, "[]!"))
+		if t == nil {
+			errs = append(errs, fmt.Errorf("unknown type %q", typName))
+			continue
+		}
+		if tc.Cost != nil && !tc.Cost.Strategy.valid() {
+			errs = append(errs, fmt.Errorf("type %s: unknown strategy %q", typName, tc.Cost.Strategy))
+		}
+		if len(tc.Fields) == 0 {
+			continue
+		}
+		ft, ok := t.(interface {
+			Fields() graphql.FieldDefinitionMap
+		})
+		if !ok {
+			errs = append(errs, fmt.Errorf("type %s: has no fields", typName))
+			continue
+		}
+		fm := ft.Fields()
+		fieldNames := make([]string, 0, len(tc.Fields))
+		for name := range tc.Fields {
+			fieldNames = append(fieldNames, name)
+		}
+		sort.Strings(fieldNames)
+		for _, fieldName := range fieldNames {
+			f, ok := fm[fieldName]
+			if !ok {
+				errs = append(errs, fmt.Errorf("type %s: unknown field %q", typName, fieldName))
+				continue
+			}
+			errs = append(errs, validateFieldCost(typName+"."+fieldName, f, tc.Fields[fieldName])...)
+		}
+	}
+	return errs
+}
+
+func validateFieldCost(where string, f *graphql.FieldDefinition, c Cost) []error {
+	var errs []error
+	if !c.Strategy.valid() {
+		errs = append(errs, fmt.Errorf("field %s: unknown strategy %q", where, c.Strategy))
+	}
+	if len(c.Multipliers) == 0 {
+		return errs
+	}
+	args := map[string]struct{}{}
+	for _, a := range f.Args {
+		args[a.PrivateName] = struct{}{}
+	}
+	for _, n := range c.Multipliers {
+		if _, ok := args[n]; !ok {
+			errs = append(errs, fmt.Errorf("field %s: multiplier %q is not an argument", where, n))
+		}
+	}
+	if !isListType(f.Type) {
+		errs = append(errs, fmt.Errorf("field %s: multipliers on non-list field", where))
+	}
+	return errs
+}
+
+func isListType(t graphql.Type) bool {
+	if nn, ok := t.(*graphql.NonNull); ok {
+		t = nn.OfType
+	}
+	_, ok := t.(*graphql.List)
+	return ok
+}
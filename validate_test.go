@@ -0,0 +1,37 @@
+package gqlcost
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	m := CostMap{
+		"Query": {Fields: FieldsCost{
+			"first":      limitCost(2),
+			"innerList":  {Multipliers: []string{"limit"}},
+			"unknown":    {Complexity: 1},
+			"customCost": {Strategy: "foo"},
+		}},
+		"First":       {Fields: FieldsCost{"second": {Complexity: 1}}},
+		"[InnerType]": {Fields: FieldsCost{"name": {Complexity: 1}}},
+		"Unknown":     {Cost: &Cost{Complexity: 1}},
+		"String":      {Fields: FieldsCost{"foo": {Complexity: 1}}},
+	}
+	exp := []string{
+		`field Query.customCost: unknown strategy "foo"`,
+		`field Query.first: multipliers on non-list field`,
+		`field Query.innerList: multiplier "limit" is not an argument`,
+		`type Query: unknown field "unknown"`,
+		`type String: has no fields`,
+		`unknown type "Unknown"`,
+	}
+	errs := m.Validate(schema)
+	if len(errs) != len(exp) {
+		t.Fatalf("unexpected number of errors: want=%d got=%d %v", len(exp), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != exp[i] {
+			t.Errorf("#%d unexpected error:\nwant=%s\ngot=%s", i, exp[i], err)
+		}
+	}
+}
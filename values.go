@@ -25,7 +25,9 @@ func isNullish(src interface{}) bool {
 	}
 	switch value.Kind() {
 	case reflect.Int:
-		return value.Int() == 0
+		// zero is a value as graphql-go v0.8 does, ex. "first: 0" is
+		// passed to resolvers.
+		return false
 	case reflect.Float32, reflect.Float64:
 		return math.IsNaN(value.Float())
 	}
@@ -127,3 +129,90 @@ func getArgumentValues(
 	//log.Printf("getArgumentValues()=%+v", results)
 	return results
 }
+
+func typeFromAST(schema *graphql.Schema, inputTypeAST ast.Type) graphql.Type {
+	switch inputTypeAST := inputTypeAST.(type) {
+	case *ast.List:
+		innerType := typeFromAST(schema, inputTypeAST.Type)
+		if innerType == nil {
+			return nil
+		}
+		return graphql.NewList(innerType)
+	case *ast.NonNull:
+		innerType := typeFromAST(schema, inputTypeAST.Type)
+		if innerType == nil {
+			return nil
+		}
+		return graphql.NewNonNull(innerType)
+	case *ast.Named:
+		if inputTypeAST.Name == nil {
+			return nil
+		}
+		return schema.Type(inputTypeAST.Name.Value)
+	default:
+		return nil
+	}
+}
+
+// Prepares an object map of variable values, which are filled with default
+// values of variable definitions when absent in inputs.
+func getVariableValues(
+	schema *graphql.Schema, definitionASTs []*ast.VariableDefinition,
+	inputs map[string]interface{}) map[string]interface{} {
+
+	values := map[string]interface{}{}
+	for k, v := range inputs {
+		values[k] = v
+	}
+	for _, defAST := range definitionASTs {
+		if defAST == nil || defAST.Variable == nil || defAST.Variable.Name == nil || defAST.DefaultValue == nil {
+			continue
+		}
+		name := defAST.Variable.Name.Value
+		if !isNullish(values[name]) {
+			continue
+		}
+		ttype, ok := typeFromAST(schema, defAST.Type).(graphql.Input)
+		if !ok {
+			continue
+		}
+		if v := valueFromAST(defAST.DefaultValue, ttype, nil); !isNullish(v) {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+// Determines if a field should be included based on the @include and @skip
+// directives, where @skip has higher precedence than @include.
+func shouldIncludeNode(directives []*ast.Directive, variableValues map[string]interface{}) bool {
+	var (
+		skipAST, includeAST *ast.Directive
+		argValues           map[string]interface{}
+	)
+	for _, directive := range directives {
+		if directive == nil || directive.Name == nil {
+			continue
+		}
+		switch directive.Name.Value {
+		case graphql.SkipDirective.Name:
+			skipAST = directive
+		case graphql.IncludeDirective.Name:
+			includeAST = directive
+		}
+	}
+	// precedence: skipAST > includeAST
+	if skipAST != nil {
+		argValues = getArgumentValues(graphql.SkipDirective.Args, skipAST.Arguments, variableValues)
+		if skipIf, ok := argValues["if"].(bool); ok && skipIf {
+			return false // excluded selectionSet's fields
+		}
+	}
+	if includeAST != nil {
+		argValues = getArgumentValues(graphql.IncludeDirective.Args, includeAST.Arguments, variableValues)
+		if includeIf, ok := argValues["if"].(bool); ok && !includeIf {
+			return false // excluded selectionSet's fields
+		}
+	}
+	return true
+}
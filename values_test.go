@@ -12,7 +12,7 @@ func TestIsNullish(t *testing.T) {
 		want bool
 	}{
 		{nil, true},
-		{0, true},
+		{0, false},
 		{1, false},
 		{-1, false},
 		{0.0, false},